@@ -0,0 +1,130 @@
+package audio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	MOVE          = 0
+	ROTATE        = 1
+	HOLD          = 2
+	LOCK          = 3
+	SINGLE        = 4
+	DOUBLE        = 5
+	TRIPLE        = 6
+	TETRIS        = 7
+	T_SPIN        = 8
+	PERFECT_CLEAR = 9
+	LEVEL_UP      = 10
+	GAME_OVER     = 11
+)
+
+var SOUND_NAMES map[int]string = map[int]string{
+	MOVE:          "move",
+	ROTATE:        "rotate",
+	HOLD:          "hold",
+	LOCK:          "lock",
+	SINGLE:        "single",
+	DOUBLE:        "double",
+	TRIPLE:        "triple",
+	TETRIS:        "tetris",
+	T_SPIN:        "t-spin",
+	PERFECT_CLEAR: "perfect clear",
+	LEVEL_UP:      "level up",
+	GAME_OVER:     "game over",
+}
+
+// Anything that can make the sounds of a game, the speakers or nothing at all
+type Player interface {
+	Play(sound int)
+	Music(playing bool)
+	// called every frame, keeps the music going
+	Update()
+}
+
+// Volumes go from 0 to 1, muting keeps them for later
+type Settings struct {
+	Volume      float32 `json:"volume"`
+	MusicVolume float32 `json:"music_volume"`
+	Muted       bool    `json:"muted"`
+}
+
+var DEFAULT_SETTINGS Settings = Settings{Volume: 0.6, MusicVolume: 0.3}
+
+func (s Settings) Validate() error {
+	if s.Volume < 0 || s.Volume > 1 || s.MusicVolume < 0 || s.MusicVolume > 1 {
+		return errors.New(fmt.Sprintf("Volumes should be between 0 and 1, found %.2f and %.2f", s.Volume, s.MusicVolume))
+	}
+
+	return nil
+}
+
+// Volume the effects and the music are actually played with
+func (s Settings) Levels() (float32, float32) {
+	if s.Muted {
+		return 0, 0
+	}
+
+	return s.Volume, s.MusicVolume
+}
+
+func DefaultSettingsPath() string {
+	configDirectory, err := os.UserConfigDir()
+	if err != nil {
+		return "audio.json"
+	}
+
+	return filepath.Join(configDirectory, "tetris", "audio.json")
+}
+
+// A missing file or value keeps the default settings
+func LoadSettings(path string) (Settings, error) {
+	settings := DEFAULT_SETTINGS
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return settings, nil
+	} else if err != nil {
+		return settings, err
+	}
+
+	if err := json.Unmarshal(content, &settings); err != nil {
+		return settings, err
+	}
+
+	return settings, settings.Validate()
+}
+
+func (s Settings) Save(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
+
+// Plays nothing and remembers what it was asked to play, for tests and games without a window
+type Recorder struct {
+	Sounds  []int
+	Playing bool // whether the music is on
+}
+
+func (r *Recorder) Play(sound int) {
+	r.Sounds = append(r.Sounds, sound)
+}
+
+func (r *Recorder) Music(playing bool) {
+	r.Playing = playing
+}
+
+func (r *Recorder) Update() {}
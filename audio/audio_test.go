@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"tetris/board"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	"tetris/spawner"
+)
+
+func play(tetrisGame *game.TetrisGame, events ...eventhandler.UpdateEvent) {
+	for _, event := range events {
+		tetrisGame.Update(event)
+	}
+}
+
+func TestSoundsOfAGame(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	initialBoard, _ := board.ParseRows([]string{".........X", "....XXXXXX"}, 10, 20)
+	tetrisGame.InitialBoard = &initialBoard
+	tetrisGame.Spawner.Sequence = spawner.NewSequence([]int{entity.I, entity.T, entity.O})
+	tetrisGame.Start()
+
+	recorder := &Recorder{}
+	Listen(tetrisGame.Events, recorder)
+	play(&tetrisGame, eventhandler.UpdateEvent{})
+	if !recorder.Playing {
+		t.Error("The music should start with the first block")
+		t.Fail()
+	}
+
+	fall := make([]eventhandler.UpdateEvent, 30)
+	for i := range fall {
+		fall[i] = eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN}
+	}
+	play(&tetrisGame, fall...)
+	play(&tetrisGame,
+		eventhandler.UpdateEvent{RotateDirection: entity.CLOCKWISE},
+		eventhandler.UpdateEvent{RotateDirection: entity.ANTI_CLOCKWISE},
+		eventhandler.UpdateEvent{MovingDirection: eventhandler.RIGHT},
+		eventhandler.UpdateEvent{MovingDirection: eventhandler.LEFT, ToWall: true},
+		eventhandler.UpdateEvent{HardDrop: true},
+		eventhandler.UpdateEvent{},
+		eventhandler.UpdateEvent{},
+		eventhandler.UpdateEvent{Hold: true},
+	)
+
+	if !slices.Contains(recorder.Sounds, MOVE) {
+		t.Errorf("Moving the block should make a sound, found %v", recorder.Sounds)
+		t.Fail()
+	}
+
+	sounds := slices.DeleteFunc(slices.Clone(recorder.Sounds), func(sound int) bool { return sound == MOVE })
+	expected := []int{ROTATE, ROTATE, LOCK, SINGLE, HOLD}
+	if !slices.Equal(sounds, expected) {
+		t.Errorf("Expected the sounds %v, found %v", expected, sounds)
+		t.Fail()
+	}
+
+	play(&tetrisGame, eventhandler.UpdateEvent{Pause: true})
+	if recorder.Playing {
+		t.Error("Pausing should stop the music")
+		t.Fail()
+	}
+
+	play(&tetrisGame, eventhandler.UpdateEvent{Pause: true})
+	if !recorder.Playing {
+		t.Error("Continuing should play the music again")
+		t.Fail()
+	}
+}
+
+func TestLevelUpAndGameOver(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	tetrisGame.Spawner.Sequence = spawner.NewSequence([]int{entity.T, entity.I})
+	tetrisGame.Start()
+
+	recorder := &Recorder{}
+	Listen(tetrisGame.Events, recorder)
+
+	// the level goes up once a minute of ticks went by
+	tetrisGame.Ticks = game.CHANGE_LEVEL_DURATION_SECOND * game.TICKS_PER_SECOND
+	play(&tetrisGame, eventhandler.UpdateEvent{})
+	if !slices.Equal(recorder.Sounds, []int{LEVEL_UP}) {
+		t.Errorf("Expected a level up, found %v", recorder.Sounds)
+		t.Fail()
+	}
+
+	// the next block has no room left
+	tetrisGame.AddGarbage(slices.Repeat([]int{0}, game.RULE_SETS["standard"].MaxHeight+1))
+	play(&tetrisGame, eventhandler.UpdateEvent{HardDrop: true}, eventhandler.UpdateEvent{}, eventhandler.UpdateEvent{})
+	if recorder.Sounds[len(recorder.Sounds)-1] != GAME_OVER || recorder.Playing {
+		t.Errorf("Topping out should play the game over and stop the music, found %v", recorder.Sounds)
+		t.Fail()
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audio.json")
+
+	if settings, err := LoadSettings(path); err != nil || settings != DEFAULT_SETTINGS {
+		t.Errorf("A missing file should give the default settings, found %v", settings)
+		t.Fail()
+	}
+
+	Settings{Volume: 0.2, MusicVolume: 0.1, Muted: true}.Save(path)
+	settings, err := LoadSettings(path)
+	if err != nil || !settings.Muted || settings.Volume != 0.2 {
+		t.Errorf("Expected the saved settings back, found %v", settings)
+		t.Fail()
+	}
+
+	if volume, musicVolume := settings.Levels(); volume != 0 || musicVolume != 0 {
+		t.Errorf("Muted settings should play nothing, found %.2f and %.2f", volume, musicVolume)
+		t.Fail()
+	}
+
+	Settings{Volume: 2}.Save(path)
+	if _, err := LoadSettings(path); err == nil {
+		t.Error("A volume above 1 should be rejected")
+		t.Fail()
+	}
+}
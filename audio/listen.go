@@ -0,0 +1,46 @@
+package audio
+
+import "tetris/game"
+
+// Plays the sounds of everything that happens in the game, the music runs while the game does.
+// The player still needs its Update every frame.
+func Listen(events *game.Events, player Player) {
+	events.Subscribe(func(event game.Event) {
+		switch event := event.(type) {
+		case game.PieceSpawned:
+			player.Music(true)
+		case game.PieceMoved:
+			player.Play(MOVE)
+		case game.PieceRotated:
+			player.Play(ROTATE)
+		case game.Hold:
+			player.Play(HOLD)
+		case game.PieceLocked:
+			player.Play(LOCK)
+		case game.LinesCleared:
+			player.Play(clear(event))
+		case game.LevelUp:
+			player.Play(LEVEL_UP)
+		case game.Paused:
+			player.Music(!event.Paused)
+		case game.GameOver:
+			player.Music(false)
+			if event.State == game.LOSE {
+				player.Play(GAME_OVER)
+			}
+		}
+	})
+}
+
+// The most special part of a clear decides its sound
+func clear(event game.LinesCleared) int {
+	if event.PerfectClear {
+		return PERFECT_CLEAR
+	} else if event.TSpin {
+		return T_SPIN
+	} else if event.Count >= 4 {
+		return TETRIS
+	}
+
+	return []int{SINGLE, DOUBLE, TRIPLE}[event.Count-1]
+}
@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	SAMPLE_RATE = 44100
+)
+
+// Frequency in hertz and length in seconds, a frequency of 0 is a rest
+type note struct {
+	frequency float64
+	length    float64
+}
+
+// The effects are synthesized so the game doesn't need any sound files
+var SOUND_NOTES map[int][]note = map[int][]note{
+	MOVE:          {{880, 0.02}},
+	ROTATE:        {{660, 0.03}, {990, 0.03}},
+	HOLD:          {{523, 0.05}, {392, 0.05}},
+	LOCK:          {{110, 0.06}},
+	SINGLE:        {{523, 0.08}},
+	DOUBLE:        {{523, 0.07}, {659, 0.08}},
+	TRIPLE:        {{523, 0.06}, {659, 0.06}, {784, 0.08}},
+	TETRIS:        {{523, 0.06}, {659, 0.06}, {784, 0.06}, {1047, 0.15}},
+	T_SPIN:        {{784, 0.05}, {622, 0.05}, {784, 0.05}, {932, 0.12}},
+	PERFECT_CLEAR: {{1047, 0.08}, {1319, 0.08}, {1568, 0.08}, {2093, 0.25}},
+	LEVEL_UP:      {{392, 0.08}, {523, 0.08}, {659, 0.08}, {784, 0.2}},
+	GAME_OVER:     {{392, 0.2}, {330, 0.2}, {262, 0.2}, {196, 0.5}},
+}
+
+// First phrase of Korobeiniki, looped for as long as the game runs
+var MUSIC_NOTES []note = []note{
+	{659, 0.4}, {494, 0.2}, {523, 0.2}, {587, 0.4}, {523, 0.2}, {494, 0.2},
+	{440, 0.4}, {440, 0.2}, {523, 0.2}, {659, 0.4}, {587, 0.2}, {523, 0.2},
+	{494, 0.6}, {523, 0.2}, {587, 0.4}, {659, 0.4},
+	{523, 0.4}, {440, 0.4}, {440, 0.4}, {0, 0.4},
+}
+
+// Plays through the audio device of raylib
+type RaylibPlayer struct {
+	Settings Settings
+	sounds   map[int]rl.Sound
+	music    rl.Sound
+	playing  bool
+}
+
+// Opens the audio device, Close closes it again
+func NewRaylibPlayer(settings Settings) *RaylibPlayer {
+	rl.InitAudioDevice()
+	player := &RaylibPlayer{Settings: settings, sounds: make(map[int]rl.Sound)}
+
+	for sound, notes := range SOUND_NOTES {
+		player.sounds[sound] = load(notes, 0.5)
+	}
+	player.music = load(MUSIC_NOTES, 0.25)
+	player.Apply(settings)
+
+	return player
+}
+
+// Changes the volumes while playing
+func (p *RaylibPlayer) Apply(settings Settings) {
+	p.Settings = settings
+	volume, musicVolume := settings.Levels()
+
+	for _, sound := range p.sounds {
+		rl.SetSoundVolume(sound, volume)
+	}
+	rl.SetSoundVolume(p.music, musicVolume)
+}
+
+func (p *RaylibPlayer) Play(sound int) {
+	if loaded, ok := p.sounds[sound]; ok {
+		rl.PlaySound(loaded)
+	}
+}
+
+func (p *RaylibPlayer) Music(playing bool) {
+	if playing && !p.playing {
+		rl.ResumeSound(p.music)
+	} else if !playing && p.playing {
+		rl.PauseSound(p.music)
+	}
+	p.playing = playing
+}
+
+func (p *RaylibPlayer) Update() {
+	if p.playing && !rl.IsSoundPlaying(p.music) {
+		rl.PlaySound(p.music)
+	}
+}
+
+func (p *RaylibPlayer) Close() {
+	for _, sound := range p.sounds {
+		rl.UnloadSound(sound)
+	}
+	rl.UnloadSound(p.music)
+	rl.CloseAudioDevice()
+}
+
+// Square waves that fade out towards the end of every note, as 16 bit mono samples
+func load(notes []note, amplitude float64) rl.Sound {
+	samples := make([]byte, 0)
+
+	for _, n := range notes {
+		count := int(n.length * SAMPLE_RATE)
+		for i := range count {
+			value := 0.0
+			if n.frequency > 0 {
+				value = amplitude * (1 - float64(i)/float64(count))
+				if math.Sin(2*math.Pi*n.frequency*float64(i)/SAMPLE_RATE) < 0 {
+					value = -value
+				}
+			}
+			samples = binary.LittleEndian.AppendUint16(samples, uint16(int16(value*math.MaxInt16)))
+		}
+	}
+
+	wave := rl.NewWave(uint32(len(samples)/2), SAMPLE_RATE, 16, 1, samples)
+	return rl.LoadSoundFromWave(wave)
+}
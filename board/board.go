@@ -0,0 +1,172 @@
+package board
+
+import (
+	"tetris/matrix"
+)
+
+const (
+	EMPTY = -1
+	// piece type of garbage cells, it comes right after the entity types
+	GARBAGE = 7
+)
+
+// Plain grid copy of the playfield, cheap to copy around for simulations.
+// Coordinates follow the game: x grows to the right, y grows downwards.
+// Cells hold the colors, Pieces the type of the piece that filled the cell or EMPTY when it isn't known.
+// Boards built by hand may leave Pieces out.
+type Board struct {
+	Width  int
+	Height int
+	Cells  [][]int
+	Pieces [][]int
+}
+
+func New(width, height int) Board {
+	return Board{Width: width, Height: height, Cells: emptyGrid(width, height), Pieces: emptyGrid(width, height)}
+}
+
+func emptyGrid(width, height int) [][]int {
+	grid := make([][]int, height)
+
+	for y := range height {
+		grid[y] = make([]int, width)
+		for x := range width {
+			grid[y][x] = EMPTY
+		}
+	}
+
+	return grid
+}
+
+func (b Board) ValidLocation(x, y int) bool {
+	return x >= 0 && y >= 0 && x < b.Width && y < b.Height
+}
+
+func (b Board) Occupied(x, y int) bool {
+	if !b.ValidLocation(x, y) {
+		return false
+	}
+
+	return b.Cells[y][x] != EMPTY
+}
+
+// Whether the given cells are all inside the board and not occupied
+func (b Board) Fits(positions matrix.Matrix) bool {
+	for _, location := range positions {
+		if !b.ValidLocation(location[0], location[1]) || b.Occupied(location[0], location[1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Type of the piece that filled the cell, EMPTY for empty cells and when it isn't known
+func (b Board) Piece(x, y int) int {
+	if !b.Occupied(x, y) || b.Pieces == nil {
+		return EMPTY
+	}
+
+	return b.Pieces[y][x]
+}
+
+// Fills a single cell with the color and the piece type
+func (b *Board) Set(x, y, color, piece int) {
+	if !b.ValidLocation(x, y) {
+		return
+	}
+
+	if b.Pieces == nil {
+		b.Pieces = emptyGrid(b.Width, b.Height)
+	}
+
+	b.Cells[y][x] = color
+	b.Pieces[y][x] = piece
+	if color == EMPTY {
+		b.Pieces[y][x] = EMPTY
+	}
+}
+
+func (b Board) Copy() Board {
+	copiedBoard := Board{Width: b.Width, Height: b.Height, Cells: copyGrid(b.Cells)}
+	if b.Pieces != nil {
+		copiedBoard.Pieces = copyGrid(b.Pieces)
+	}
+
+	return copiedBoard
+}
+
+func copyGrid(grid [][]int) [][]int {
+	copiedGrid := make([][]int, len(grid))
+
+	for y := range grid {
+		copiedGrid[y] = make([]int, len(grid[y]))
+		copy(copiedGrid[y], grid[y])
+	}
+
+	return copiedGrid
+}
+
+func (b *Board) Place(positions matrix.Matrix, value int) {
+	for _, location := range positions {
+		if b.ValidLocation(location[0], location[1]) {
+			b.Cells[location[1]][location[0]] = value
+		}
+	}
+}
+
+// Removes every full row and shifts the rows above it down, returns the total removed rows
+func (b *Board) ClearLines() int {
+	remainingRows := make([]int, 0, b.Height)
+
+	for y := range b.Height {
+		if !b.RowFull(y) {
+			remainingRows = append(remainingRows, y)
+		}
+	}
+
+	totalCleared := b.Height - len(remainingRows)
+	b.Cells = b.keepRows(b.Cells, remainingRows)
+	if b.Pieces != nil {
+		b.Pieces = b.keepRows(b.Pieces, remainingRows)
+	}
+
+	return totalCleared
+}
+
+// Only the given rows of the grid, moved to the bottom with empty rows above them
+func (b Board) keepRows(grid [][]int, rows []int) [][]int {
+	keptGrid := emptyGrid(b.Width, b.Height-len(rows))
+
+	for _, y := range rows {
+		keptGrid = append(keptGrid, grid[y])
+	}
+
+	return keptGrid
+}
+
+func (b Board) RowFull(y int) bool {
+	for x := range b.Width {
+		if b.Cells[y][x] == EMPTY {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Height of each column counted from the bottom of the board, 0 for an empty column
+func (b Board) ColumnHeights() []int {
+	heights := make([]int, b.Width)
+
+	for x := range b.Width {
+		for y := range b.Height {
+			if b.Cells[y][x] != EMPTY {
+				heights[x] = b.Height - y
+				break
+			}
+		}
+	}
+
+	return heights
+}
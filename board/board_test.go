@@ -0,0 +1,70 @@
+package board
+
+import (
+	"testing"
+	"tetris/matrix"
+)
+
+func TestClearLinesShouldShiftRowsAbove(t *testing.T) {
+	board := New(4, 4)
+
+	board.Place(matrix.Matrix{{0, 3}, {1, 3}, {2, 3}, {3, 3}}, 1)
+	board.Place(matrix.Matrix{{1, 2}}, 2)
+	board.Place(matrix.Matrix{{0, 1}, {1, 1}, {2, 1}, {3, 1}}, 1)
+
+	totalCleared := board.ClearLines()
+
+	if totalCleared != 2 {
+		t.Errorf("Total cleared lines should be 2, found %d instead", totalCleared)
+		t.Fail()
+	}
+
+	if board.Cells[3][1] != 2 {
+		t.Errorf("Remaining block should have fallen to the bottom, found %d instead", board.Cells[3][1])
+		t.Fail()
+	}
+
+	for y := range 3 {
+		for x := range 4 {
+			if board.Occupied(x, y) {
+				t.Errorf("Location x: %d y: %d should be empty", x, y)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestColumnHeights(t *testing.T) {
+	board := New(3, 5)
+	board.Place(matrix.Matrix{{0, 4}, {2, 1}}, 0)
+
+	heights := board.ColumnHeights()
+	expectedHeights := []int{1, 0, 4}
+
+	for i := range expectedHeights {
+		if heights[i] != expectedHeights[i] {
+			t.Errorf("Height of column %d should be %d, found %d instead", i, expectedHeights[i], heights[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestFitsShouldRejectOutOfBoundsAndOccupied(t *testing.T) {
+	board := New(3, 3)
+	board.Place(matrix.Matrix{{1, 1}}, 0)
+
+	if board.Fits(matrix.Matrix{{0, 0}, {1, 1}}) {
+		t.Error("Position overlapping an occupied cell should not fit")
+		t.Fail()
+	}
+
+	if board.Fits(matrix.Matrix{{2, 2}, {3, 2}}) {
+		t.Error("Position outside of the board should not fit")
+		t.Fail()
+	}
+
+	if !board.Fits(matrix.Matrix{{0, 0}, {2, 2}}) {
+		t.Error("Position on empty cells should fit")
+		t.Fail()
+	}
+}
@@ -0,0 +1,262 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"tetris/entity"
+	"tetris/matrix"
+	"unicode"
+)
+
+// Plain text form of a board: one row per line from top to bottom, '.' for an empty cell, the letter of the piece
+// that filled a cell, G for garbage and X for a filled cell of an unknown piece. The cells of a block that is
+// still falling are written in lower case.
+const (
+	EMPTY_LETTER   = '.'
+	GARBAGE_LETTER = 'G'
+	UNKNOWN_LETTER = 'X'
+)
+
+var PIECE_LETTERS map[int]rune = map[int]rune{
+	entity.I: 'I',
+	entity.J: 'J',
+	entity.L: 'L',
+	entity.O: 'O',
+	entity.S: 'S',
+	entity.T: 'T',
+	entity.Z: 'Z',
+	GARBAGE:  GARBAGE_LETTER,
+}
+
+// Piece type of a letter, EMPTY for the unknown letter
+func LetterPiece(letter rune) (int, bool) {
+	if unicode.ToUpper(letter) == UNKNOWN_LETTER {
+		return EMPTY, true
+	}
+
+	for piece, pieceLetter := range PIECE_LETTERS {
+		if unicode.ToUpper(letter) == pieceLetter {
+			return piece, true
+		}
+	}
+
+	return EMPTY, false
+}
+
+// Color a cell of the piece is drawn with
+func PieceColor(piece int) int {
+	if color, ok := entity.PIECE_COLORS[piece]; ok {
+		return color
+	}
+
+	return entity.GRAY
+}
+
+// Board exactly as large as the text
+func Parse(text string) (Board, error) {
+	rows := lines(text)
+	if len(rows) == 0 {
+		return Board{}, errors.New("Board text has no rows")
+	}
+
+	return ParseRows(rows, len(rows[0]), len(rows))
+}
+
+// Board of the given size with the rows at its bottom, the rows above them stay empty
+func ParseRows(rows []string, width, height int) (Board, error) {
+	b, falling, err := parseCells(rows, width, height)
+	if err != nil {
+		return Board{}, err
+	}
+
+	if len(falling) > 0 {
+		return Board{}, errors.New("Board text should not have a falling block, lower case letters are only for placements")
+	}
+
+	return b, nil
+}
+
+// Board and the falling block written in lower case on it
+func ParsePlacement(text string) (Board, entity.BlockEntity, error) {
+	rows := lines(text)
+	if len(rows) == 0 {
+		return Board{}, entity.BlockEntity{}, errors.New("Board text has no rows")
+	}
+
+	b, falling, err := parseCells(rows, len(rows[0]), len(rows))
+	if err != nil {
+		return Board{}, entity.BlockEntity{}, err
+	}
+
+	pieces := make([]int, 0)
+	for _, cell := range falling {
+		if !slices.Contains(pieces, cell[2]) {
+			pieces = append(pieces, cell[2])
+		}
+	}
+
+	if len(pieces) != 1 || pieces[0] == GARBAGE || pieces[0] == EMPTY {
+		return Board{}, entity.BlockEntity{}, errors.New("Placement should have the cells of exactly one falling block")
+	}
+
+	positions := make(matrix.Matrix, len(falling))
+	for i, cell := range falling {
+		positions[i] = []int{cell[0], cell[1]}
+	}
+
+	block, err := BlockAt(pieces[0], positions)
+	return b, block, err
+}
+
+func parseCells(rows []string, width, height int) (Board, [][3]int, error) {
+	if len(rows) > height {
+		return Board{}, nil, errors.New(fmt.Sprintf("Board text has %d rows, at most %d fit", len(rows), height))
+	}
+
+	b := New(width, height)
+	falling := make([][3]int, 0)
+
+	for i, row := range rows {
+		y := height - len(rows) + i
+		if len([]rune(row)) != width {
+			return Board{}, nil, errors.New(fmt.Sprintf("Row %q should be %d cells wide", row, width))
+		}
+
+		for x, letter := range []rune(row) {
+			if letter == EMPTY_LETTER {
+				continue
+			}
+
+			piece, ok := LetterPiece(letter)
+			if !ok {
+				return Board{}, nil, errors.New(fmt.Sprintf("Unknown cell %q in row %q", letter, row))
+			}
+
+			if unicode.IsLower(letter) {
+				falling = append(falling, [3]int{x, y, piece})
+				continue
+			}
+			b.Set(x, y, PieceColor(piece), piece)
+		}
+	}
+
+	return b, falling, nil
+}
+
+// Block of the piece on the given cells, in the same order entity.New and the rotations would give them.
+// The rotation center is picked by index so the order matters.
+func BlockAt(piece int, positions matrix.Matrix) (entity.BlockEntity, error) {
+	target := normalized(positions)
+
+	block, err := entity.New(piece, PieceColor(piece), [2]int{0, 0})
+	if err != nil {
+		return entity.BlockEntity{}, err
+	}
+
+	for range 4 {
+		if slices.Equal(normalized(block.OccupiedPosition), target) {
+			parsedMin, blockMin := minimum(positions), minimum(block.OccupiedPosition)
+			block.MoveBlock([2]int{parsedMin[0] - blockMin[0], parsedMin[1] - blockMin[1]})
+			return block, nil
+		}
+		block.RotateBlock(entity.CLOCKWISE)
+	}
+
+	return entity.BlockEntity{}, errors.New(fmt.Sprintf("Cells %v don't make up a %c block", positions, PIECE_LETTERS[piece]))
+}
+
+// Cells moved next to the origin and sorted, the same shape always gives the same cells
+func normalized(positions matrix.Matrix) []string {
+	origin := minimum(positions)
+	cells := make([]string, len(positions))
+
+	for i, location := range positions {
+		cells[i] = fmt.Sprintf("%d,%d", location[0]-origin[0], location[1]-origin[1])
+	}
+	slices.Sort(cells)
+
+	return cells
+}
+
+func minimum(positions matrix.Matrix) [2]int {
+	origin := [2]int{positions[0][0], positions[0][1]}
+
+	for _, location := range positions {
+		origin[0] = min(origin[0], location[0])
+		origin[1] = min(origin[1], location[1])
+	}
+
+	return origin
+}
+
+func lines(text string) []string {
+	rows := make([]string, 0)
+
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			rows = append(rows, line)
+		}
+	}
+
+	return rows
+}
+
+func (b Board) letter(x, y int) rune {
+	if !b.Occupied(x, y) {
+		return EMPTY_LETTER
+	}
+
+	if letter, ok := PIECE_LETTERS[b.Piece(x, y)]; ok {
+		return letter
+	}
+
+	return UNKNOWN_LETTER
+}
+
+// Every row of the board in the text form
+func (b Board) Rows() []string {
+	rows := make([]string, b.Height)
+
+	for y := range b.Height {
+		row := make([]rune, b.Width)
+		for x := range b.Width {
+			row[x] = b.letter(x, y)
+		}
+		rows[y] = string(row)
+	}
+
+	return rows
+}
+
+// Rows from the highest filled one down, the empty rows above the stack are left out
+func (b Board) StackRows() []string {
+	rows := b.Rows()
+
+	for len(rows) > 0 && strings.Trim(rows[0], string(EMPTY_LETTER)) == "" {
+		rows = rows[1:]
+	}
+
+	return rows
+}
+
+func (b Board) String() string {
+	return strings.Join(b.Rows(), "\n")
+}
+
+// The board with the falling block written over it in lower case
+func (b Board) PrintPlacement(block entity.BlockEntity) string {
+	rows := b.Rows()
+
+	for _, location := range block.OccupiedPosition {
+		x, y := location[0], location[1]
+		if b.ValidLocation(x, y) {
+			row := []rune(rows[y])
+			row[x] = unicode.ToLower(PIECE_LETTERS[block.EntityType])
+			rows[y] = string(row)
+		}
+	}
+
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,123 @@
+package board
+
+import (
+	"strings"
+	"testing"
+	"tetris/entity"
+)
+
+func TestParseAndPrint(t *testing.T) {
+	text := strings.Join([]string{
+		"....",
+		"T...",
+		"TTGX",
+		"IIII",
+	}, "\n")
+
+	board, err := Parse(text)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if board.Width != 4 || board.Height != 4 {
+		t.Errorf("Board should be 4 by 4, found %d by %d", board.Width, board.Height)
+		t.FailNow()
+	}
+
+	if board.Piece(0, 1) != entity.T || board.Piece(2, 2) != GARBAGE || board.Piece(3, 2) != EMPTY || !board.Occupied(3, 2) {
+		t.Errorf("Cells should keep their pieces, found\n%s", board)
+		t.Fail()
+	}
+
+	if board.Cells[3][0] != entity.PIECE_COLORS[entity.I] || board.Cells[2][2] != entity.GRAY {
+		t.Error("Cells should get the colors of their pieces")
+		t.Fail()
+	}
+
+	if printed := board.String(); printed != text {
+		t.Errorf("Printing should give the same text back, found\n%s", printed)
+		t.Fail()
+	}
+
+	board.ClearLines()
+	if printed := strings.Join(board.StackRows(), "\n"); printed != "T..." || board.Piece(0, 3) != entity.T {
+		t.Errorf("Pieces should move down with their cells, found\n%s", printed)
+		t.Fail()
+	}
+}
+
+func TestParseRowsSitOnTheFloor(t *testing.T) {
+	board, err := ParseRows([]string{"SS..", ".SS."}, 4, 6)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if board.Piece(0, 4) != entity.S || board.Piece(2, 5) != entity.S || board.Occupied(0, 3) {
+		t.Errorf("Rows should sit on the floor, found\n%s", board)
+		t.Fail()
+	}
+
+	for _, rows := range [][]string{{"SS."}, {"SS.Q"}, {"....", "....", "....", "....", "....", "....", "...."}} {
+		if _, err := ParseRows(rows, 4, 6); err == nil {
+			t.Errorf("Rows %v should be rejected", rows)
+			t.Fail()
+		}
+	}
+}
+
+func TestPlacementRoundTrip(t *testing.T) {
+	text := strings.Join([]string{
+		"....",
+		".t..",
+		"ttt.",
+		"GGG.",
+	}, "\n")
+
+	board, block, err := ParsePlacement(text)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	expected, _ := entity.New(entity.T, entity.RED, [2]int{1, 1})
+	if block.EntityType != entity.T || !block.OccupiedPosition.Equal(expected.OccupiedPosition) {
+		t.Errorf("Expected the cells of a new T in order %v, found %v", expected.OccupiedPosition, block.OccupiedPosition)
+		t.Fail()
+	}
+
+	if board.Occupied(1, 1) || !board.Occupied(0, 3) {
+		t.Error("Falling block should not be part of the board")
+		t.Fail()
+	}
+
+	if printed := board.PrintPlacement(block); printed != text {
+		t.Errorf("Printing should give the same text back, found\n%s", printed)
+		t.Fail()
+	}
+}
+
+func TestPlacementKeepsTheRotationCenter(t *testing.T) {
+	block, _ := entity.New(entity.L, entity.RED, [2]int{2, 0})
+	block.RotateBlock(entity.CLOCKWISE)
+	board := New(5, 5)
+
+	_, parsed, err := ParsePlacement(board.PrintPlacement(block))
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	parsed.RotateBlock(entity.CLOCKWISE)
+	block.RotateBlock(entity.CLOCKWISE)
+	if !parsed.OccupiedPosition.Equal(block.OccupiedPosition) {
+		t.Errorf("Parsed block should rotate like the original, found %v and %v", parsed.OccupiedPosition, block.OccupiedPosition)
+		t.Fail()
+	}
+
+	if _, _, err := ParsePlacement("t..\nt..\nt.."); err == nil {
+		t.Error("Three cells are no T")
+		t.Fail()
+	}
+}
@@ -0,0 +1,203 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"tetris/board"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	"tetris/matrix"
+	movegenerator "tetris/move_generator"
+)
+
+type Weights struct {
+	AggregateHeight float64
+	Holes           float64
+	Bumpiness       float64
+	Wells           float64
+	LinesCleared    float64
+}
+
+// Weights Yiyuan Lee found with a genetic algorithm, wells aren't one of his features and got a small penalty by hand
+var DEFAULT_WEIGHTS Weights = Weights{
+	AggregateHeight: -0.510066,
+	Holes:           -0.35663,
+	Bumpiness:       -0.184483,
+	Wells:           -0.1,
+	LinesCleared:    0.760666,
+}
+
+type Placement struct {
+	movegenerator.Placement
+	Score float64
+}
+
+type Bot struct {
+	Weights      Weights
+	Randomizer   *rand.Rand // random bots ignore the weights and pick any reachable placement
+	currentBlock *entity.BlockEntity
+	follower     *movegenerator.Follower
+}
+
+// Lets the bot act as the input source of a game, used for the demo mode
+type Player struct {
+	Bot  *Bot
+	Game *game.TetrisGame
+}
+
+func (p Player) HandleEvent() eventhandler.UpdateEvent {
+	return p.Bot.NextEvent(p.Game)
+}
+
+func New(weights Weights) Bot {
+	return Bot{Weights: weights}
+}
+
+// Lower bound to compare other bots against, also good at exercising odd corners of the rules
+func NewRandom(seed int64) Bot {
+	return Bot{Randomizer: rand.New(rand.NewSource(seed))}
+}
+
+// Every position the block can reach and lock on, scored by the heuristic
+func (b Bot) Placements(currentBoard board.Board, block entity.BlockEntity) []Placement {
+	placements := make([]Placement, 0)
+
+	for _, placement := range movegenerator.Generate(currentBoard, block) {
+		placements = append(placements, Placement{
+			Placement: placement,
+			Score:     b.Evaluate(currentBoard, placement.Position),
+		})
+	}
+
+	return placements
+}
+
+func (b Bot) BestPlacement(currentBoard board.Board, block entity.BlockEntity) (Placement, error) {
+	placements := b.Placements(currentBoard, block)
+
+	if len(placements) == 0 {
+		return Placement{}, errors.New(fmt.Sprintf("No placement found for block type %d", block.EntityType))
+	}
+
+	if b.Randomizer != nil {
+		return placements[b.Randomizer.Intn(len(placements))], nil
+	}
+
+	best := placements[0]
+	for _, placement := range placements[1:] {
+		if placement.Score > best.Score {
+			best = placement
+		}
+	}
+
+	return best, nil
+}
+
+// Scores the board after the given cells are locked, higher is better
+func (b Bot) Evaluate(currentBoard board.Board, position matrix.Matrix) float64 {
+	resultingBoard := currentBoard.Copy()
+	resultingBoard.Place(position, 0)
+	linesCleared := resultingBoard.ClearLines()
+
+	heights := resultingBoard.ColumnHeights()
+	aggregateHeight, holes, bumpiness, wells := 0, 0, 0, 0
+
+	for x, height := range heights {
+		aggregateHeight += height
+
+		for y := resultingBoard.Height - height; y < resultingBoard.Height; y++ {
+			if !resultingBoard.Occupied(x, y) {
+				holes += 1
+			}
+		}
+
+		if x > 0 {
+			bumpiness += int(math.Abs(float64(height - heights[x-1])))
+		}
+
+		leftHeight, rightHeight := resultingBoard.Height, resultingBoard.Height
+		if x > 0 {
+			leftHeight = heights[x-1]
+		}
+		if x < len(heights)-1 {
+			rightHeight = heights[x+1]
+		}
+		if depth := min(leftHeight, rightHeight) - height; depth > 0 {
+			wells += depth
+		}
+	}
+
+	return b.Weights.AggregateHeight*float64(aggregateHeight) +
+		b.Weights.Holes*float64(holes) +
+		b.Weights.Bumpiness*float64(bumpiness) +
+		b.Weights.Wells*float64(wells) +
+		b.Weights.LinesCleared*float64(linesCleared)
+}
+
+// Inputs of the path to the placement, one event per step. A soft drop only speeds up the gravity, so played
+// one per frame these don't bring the block there, the Follower repeats each one until the block actually falls.
+func (p Placement) Inputs() []eventhandler.UpdateEvent {
+	inputs := make([]eventhandler.UpdateEvent, len(p.Path))
+
+	for i, input := range p.Path {
+		inputs[i] = movegenerator.ToEvent(input)
+	}
+
+	return inputs
+}
+
+// Picks the input for the current frame, the placement is only chosen once per block
+func (b *Bot) NextEvent(tg *game.TetrisGame) eventhandler.UpdateEvent {
+	if tg.State != game.PLAY || tg.BlockState != game.MOVING_BLOCK || tg.CurrentBlock == nil {
+		return eventhandler.UpdateEvent{}
+	}
+
+	currentBoard := tg.Board()
+
+	if b.currentBlock != tg.CurrentBlock {
+		b.currentBlock = tg.CurrentBlock
+		b.follow(currentBoard, *tg.CurrentBlock)
+	}
+
+	if b.follower == nil {
+		return eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN}
+	}
+
+	event, err := b.follower.NextEvent(currentBoard, *tg.CurrentBlock)
+	if err != nil {
+		// gravity took the block past the point of no return, settle for the best of what is left
+		b.follow(currentBoard, *tg.CurrentBlock)
+		return eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN}
+	}
+
+	return event
+}
+
+func (b *Bot) follow(currentBoard board.Board, block entity.BlockEntity) {
+	b.follower = nil
+
+	placement, err := b.BestPlacement(currentBoard, block)
+	if err != nil {
+		return
+	}
+
+	follower, err := movegenerator.NewFollower(currentBoard, block, placement.Position)
+	if err == nil {
+		b.follower = &follower
+	}
+}
+
+// Plays the game headlessly until it is lost or maxTicks has passed, returns the amount of ticks played
+func (b *Bot) Run(tg *game.TetrisGame, maxTicks int) int {
+	ticks := 0
+
+	for tg.State == game.PLAY && ticks < maxTicks {
+		tg.Update(b.NextEvent(tg))
+		ticks += 1
+	}
+
+	return ticks
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"math/rand"
+	"testing"
+	"tetris/board"
+	"tetris/collision"
+	"tetris/entity"
+	"tetris/game"
+	"tetris/matrix"
+	"tetris/spawner"
+	treecoordinate "tetris/tree_coordinate"
+	renderer "tetris/ui"
+)
+
+func newHeadlessGame(seed int64) game.TetrisGame {
+	collisionDetector := collision.Collision{MaxWitdh: 9, MaxHeight: 19, OccupiedBlocks: treecoordinate.New()}
+	spawnerBlock := spawner.BlockSpawner{MaxWidth: 9, Randomizer: *rand.New(rand.NewSource(seed))}
+	return game.New(9, 19, collisionDetector, spawnerBlock, renderer.Renderer{}, 4, 0)
+}
+
+func TestPlacementsShouldLandOnTheFloor(t *testing.T) {
+	emptyBoard := board.New(10, 20)
+	block, _ := entity.New(entity.I, entity.RED, [2]int{3, 0})
+
+	placements := New(DEFAULT_WEIGHTS).Placements(emptyBoard, block)
+
+	// 7 horizontal positions and 10 vertical ones
+	if len(placements) != 17 {
+		t.Errorf("I block should have 17 placements on an empty board, found %d instead", len(placements))
+		t.Fail()
+	}
+
+	for _, placement := range placements {
+		maxY := 0
+		for _, location := range placement.Position {
+			maxY = max(maxY, location[1])
+		}
+
+		if maxY != 19 {
+			t.Errorf("Placement should rest on the floor\n%s", placement.Position.ToString())
+			t.Fail()
+		}
+	}
+}
+
+func TestBestPlacementShouldAvoidHoles(t *testing.T) {
+	currentBoard := board.New(4, 4)
+	currentBoard.Place(matrix.Matrix{{0, 3}, {1, 3}, {3, 3}}, 0)
+	block, _ := entity.New(entity.T, entity.RED, [2]int{1, 0})
+
+	placement, err := New(DEFAULT_WEIGHTS).BestPlacement(currentBoard, block)
+
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	filled := false
+	for _, location := range placement.Position {
+		filled = filled || (location[0] == 2 && location[1] == 3)
+	}
+
+	if !filled {
+		t.Errorf("Best placement should fill the gap on the bottom row\n%s", placement.Position.ToString())
+		t.Fail()
+	}
+}
+
+func TestRunShouldClearLines(t *testing.T) {
+	tetrisGame := newHeadlessGame(42069)
+	tetrisGame.Start()
+	heuristicBot := New(DEFAULT_WEIGHTS)
+
+	heuristicBot.Run(&tetrisGame, 20000)
+
+	if tetrisGame.Score == 0 {
+		t.Errorf("Bot should have cleared at least a line, game state %d", tetrisGame.State)
+		t.Fail()
+	}
+}
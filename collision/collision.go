@@ -65,7 +65,6 @@ func (c Collision) RemoveBlock(y int) error {
 		j -= 1
 	}
 
-	fmt.Println(len(removedBlocks))
 	for _, val := range removedBlocks {
 		c.AddOccupiedBlocks(val[0], val[1]+1)
 	}
@@ -97,3 +96,45 @@ func (c *Collision) AddOccupiedBlocks(x, y int) error {
 func (c Collision) GetAllBlocks() [][2]float32 {
 	return c.OccupiedBlocks.GetAllCoordinate()
 }
+
+// Pushes every block up and fills one row from the bottom for every hole, holes[0] being the hole of the lowest row.
+// Blocks pushed past the top are dropped, returns true when that happens.
+func (c *Collision) InsertGarbage(holes []int) bool {
+	rows := len(holes)
+	blocks := c.GetAllBlocks()
+	toppedOut := false
+
+	for y := range c.MaxHeight + 1 {
+		for x := range c.MaxWitdh + 1 {
+			if c.Collide(x, y) {
+				c.RemoveOccupiedBlocks(x, y)
+			}
+		}
+		c.OccupiedBlocks.RemoveAll(y)
+	}
+
+	for _, location := range blocks {
+		x, y := int(location[0]), int(location[1])
+		if y-rows < 0 {
+			toppedOut = true
+			continue
+		}
+
+		c.AddOccupiedBlocks(x, y-rows)
+	}
+
+	for i, hole := range holes {
+		y := c.MaxHeight - i
+		if y < 0 {
+			return true
+		}
+
+		for x := range c.MaxWitdh + 1 {
+			if x != hole {
+				c.AddOccupiedBlocks(x, y)
+			}
+		}
+	}
+
+	return toppedOut
+}
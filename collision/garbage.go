@@ -0,0 +1,68 @@
+package collision
+
+import "math/rand"
+
+// Decides where the holes of incoming garbage rows are, the first hole belongs to the lowest row
+type HoleStrategy interface {
+	Holes(rows, width int) []int
+}
+
+// Every row of every attack shares the same column, the easiest garbage to dig through
+type CleanHoles struct {
+	Randomizer *rand.Rand
+	column     int
+	chosen     bool
+}
+
+// Every attack gets its own column, the rows of a single attack line up
+type RandomHoles struct {
+	Randomizer *rand.Rand
+}
+
+// Every row gets its own column
+type MessyHoles struct {
+	Randomizer *rand.Rand
+}
+
+var HOLE_STRATEGIES map[string]func(seed int64) HoleStrategy = map[string]func(seed int64) HoleStrategy{
+	"clean": func(seed int64) HoleStrategy {
+		return &CleanHoles{Randomizer: rand.New(rand.NewSource(seed))}
+	},
+	"random": func(seed int64) HoleStrategy {
+		return &RandomHoles{Randomizer: rand.New(rand.NewSource(seed))}
+	},
+	"messy": func(seed int64) HoleStrategy {
+		return &MessyHoles{Randomizer: rand.New(rand.NewSource(seed))}
+	},
+}
+
+func (h *CleanHoles) Holes(rows, width int) []int {
+	if !h.chosen {
+		h.column = h.Randomizer.Intn(width)
+		h.chosen = true
+	}
+
+	return repeat(h.column, rows)
+}
+
+func (h *RandomHoles) Holes(rows, width int) []int {
+	return repeat(h.Randomizer.Intn(width), rows)
+}
+
+func (h *MessyHoles) Holes(rows, width int) []int {
+	holes := make([]int, rows)
+	for i := range holes {
+		holes[i] = h.Randomizer.Intn(width)
+	}
+
+	return holes
+}
+
+func repeat(column, rows int) []int {
+	holes := make([]int, rows)
+	for i := range holes {
+		holes[i] = column
+	}
+
+	return holes
+}
@@ -0,0 +1,97 @@
+package collision
+
+import (
+	"testing"
+	treecoordinate "tetris/tree_coordinate"
+)
+
+func newCollision() Collision {
+	return Collision{MaxWitdh: 9, MaxHeight: 19, OccupiedBlocks: treecoordinate.New()}
+}
+
+func TestInsertGarbage(t *testing.T) {
+	c := newCollision()
+	c.AddOccupiedBlocks(4, 19)
+	c.AddOccupiedBlocks(4, 18)
+
+	if c.InsertGarbage([]int{1, 7, 2}) {
+		t.Error("Three rows should not top out an almost empty board")
+		t.FailNow()
+	}
+
+	if !c.Collide(4, 16) || !c.Collide(4, 15) || c.Collide(4, 14) {
+		t.Error("Existing blocks should move up by three rows")
+		t.Fail()
+	}
+
+	for i, hole := range []int{1, 7, 2} {
+		y := 19 - i
+		for x := range 10 {
+			if c.Collide(x, y) == (x == hole) {
+				t.Errorf("Row %d should only have a hole at x: %d, x: %d is wrong", y, hole, x)
+				t.Fail()
+			}
+		}
+
+		if c.GetYCount(y) != 9 {
+			t.Errorf("Row %d should count 9 blocks, found %d", y, c.GetYCount(y))
+			t.Fail()
+		}
+	}
+
+	if c.GetYCount(16) != 1 {
+		t.Errorf("Moved row should count 1 block, found %d", c.GetYCount(16))
+		t.Fail()
+	}
+}
+
+func TestInsertGarbageTopsOut(t *testing.T) {
+	c := newCollision()
+	c.AddOccupiedBlocks(0, 1)
+
+	if !c.InsertGarbage([]int{0, 0}) {
+		t.Error("Block pushed past the top should be reported")
+		t.Fail()
+	}
+
+	if c.Collide(0, 0) {
+		t.Error("Block pushed past the top should be dropped")
+		t.Fail()
+	}
+}
+
+func TestHoleStrategies(t *testing.T) {
+	clean := HOLE_STRATEGIES["clean"](1)
+	first, second := clean.Holes(3, 10), clean.Holes(2, 10)
+	for _, hole := range append(first, second...) {
+		if hole != first[0] {
+			t.Errorf("Clean garbage should always use the same hole, found %v and %v", first, second)
+			t.Fail()
+		}
+	}
+
+	random := HOLE_STRATEGIES["random"](1)
+	holes := random.Holes(4, 10)
+	for _, hole := range holes {
+		if hole != holes[0] {
+			t.Errorf("Rows of a single random attack should line up, found %v", holes)
+			t.Fail()
+		}
+	}
+
+	messy := HOLE_STRATEGIES["messy"](1)
+	holes = messy.Holes(20, 10)
+	different := false
+	for _, hole := range holes {
+		if hole < 0 || hole >= 10 {
+			t.Errorf("Hole %d is outside of the board", hole)
+			t.Fail()
+		}
+		different = different || hole != holes[0]
+	}
+
+	if !different {
+		t.Errorf("Messy garbage should move its hole around, found %v", holes)
+		t.Fail()
+	}
+}
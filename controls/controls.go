@@ -0,0 +1,74 @@
+package controls
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	eventhandler "tetris/event_handler"
+)
+
+// State of the rebinding screen. While capturing, the next key or button pressed gets bound to the selected action
+type Screen struct {
+	Bindings  eventhandler.Bindings
+	Defaults  eventhandler.Bindings // what resetting goes back to, e.g. the bindings of one of the versus players
+	Selected  int
+	Capturing bool
+}
+
+func New(bindings eventhandler.Bindings, defaults eventhandler.Bindings) Screen {
+	return Screen{Bindings: bindings.Copy(), Defaults: defaults}
+}
+
+func (s Screen) action() string {
+	return eventhandler.ACTIONS[s.Selected]
+}
+
+func (s *Screen) Move(delta int) {
+	s.Selected = (s.Selected + delta + len(eventhandler.ACTIONS)) % len(eventhandler.ACTIONS)
+}
+
+// Adds the key to the selected action, an action that had it before loses it
+func (s *Screen) BindKey(key int32) {
+	for action, binding := range s.Bindings {
+		binding.Keys = slices.DeleteFunc(binding.Keys, func(bound int32) bool { return bound == key })
+		s.Bindings[action] = binding
+	}
+
+	binding := s.Bindings[s.action()]
+	binding.Keys = append(binding.Keys, key)
+	s.Bindings[s.action()] = binding
+	s.Capturing = false
+}
+
+func (s *Screen) BindButton(button int32) {
+	for action, binding := range s.Bindings {
+		binding.Buttons = slices.DeleteFunc(binding.Buttons, func(bound int32) bool { return bound == button })
+		s.Bindings[action] = binding
+	}
+
+	binding := s.Bindings[s.action()]
+	binding.Buttons = append(binding.Buttons, button)
+	s.Bindings[s.action()] = binding
+	s.Capturing = false
+}
+
+func (s *Screen) Clear() {
+	s.Bindings[s.action()] = eventhandler.Binding{Keys: make([]int32, 0), Buttons: make([]int32, 0)}
+}
+
+func (s *Screen) Reset() {
+	s.Bindings = s.Defaults.Copy()
+}
+
+func (s Screen) options() []string {
+	options := make([]string, len(eventhandler.ACTIONS))
+	for i, action := range eventhandler.ACTIONS {
+		description := s.Bindings.Describe(action)
+		if s.Capturing && i == s.Selected {
+			description = "press a key or button"
+		}
+		options[i] = fmt.Sprintf("%s: %s", strings.ReplaceAll(action, "_", " "), description)
+	}
+
+	return options
+}
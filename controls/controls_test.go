@@ -0,0 +1,103 @@
+package controls
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	eventhandler "tetris/event_handler"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestBindMovesKey(t *testing.T) {
+	screen := New(eventhandler.DEFAULT_BINDINGS, eventhandler.DEFAULT_BINDINGS)
+	screen.Move(-1)
+	if screen.action() != eventhandler.PAUSE {
+		t.Errorf("Moving up from the first action should select the last one, found %s", screen.action())
+		t.FailNow()
+	}
+
+	screen.Capturing = true
+	screen.BindKey(rl.KeySpace)
+	screen.BindButton(rl.GamepadButtonLeftFaceUp)
+
+	pause := screen.Bindings[eventhandler.PAUSE]
+	hardDrop := screen.Bindings[eventhandler.HARD_DROP]
+	if screen.Capturing || !slices.Contains(pause.Keys, rl.KeySpace) || !slices.Contains(pause.Buttons, rl.GamepadButtonLeftFaceUp) {
+		t.Errorf("Space and the dpad should pause, found %s", screen.Bindings.Describe(eventhandler.PAUSE))
+		t.Fail()
+	}
+
+	if len(hardDrop.Keys) != 0 || len(hardDrop.Buttons) != 0 {
+		t.Errorf("Hard drop should lose its key and button, found %s", screen.Bindings.Describe(eventhandler.HARD_DROP))
+		t.Fail()
+	}
+
+	if !slices.Contains(eventhandler.DEFAULT_BINDINGS[eventhandler.HARD_DROP].Keys, rl.KeySpace) {
+		t.Error("Rebinding should leave the default bindings alone")
+		t.Fail()
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "controls.json")
+	screen := New(eventhandler.DEFAULT_BINDINGS, eventhandler.DEFAULT_BINDINGS)
+	screen.Clear()
+	screen.BindKey(rl.KeyJ)
+	screen.BindKey(rl.KeyF1)
+
+	if err := screen.Bindings.Save(path); err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	loaded, err := eventhandler.LoadBindings(path, eventhandler.DEFAULT_BINDINGS)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	for _, action := range eventhandler.ACTIONS {
+		if loaded.Describe(action) != screen.Bindings.Describe(action) {
+			t.Errorf("%s should be bound to %s, found %s", action, screen.Bindings.Describe(action), loaded.Describe(action))
+			t.Fail()
+		}
+	}
+
+	if loaded.Describe(eventhandler.MOVE_LEFT) != "J, F1" {
+		t.Errorf("Moving left should only be bound to J and F1, found %s", loaded.Describe(eventhandler.MOVE_LEFT))
+		t.Fail()
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	loaded, err := eventhandler.LoadBindings(filepath.Join(t.TempDir(), "missing.json"), eventhandler.DEFAULT_BINDINGS)
+	if err != nil || loaded.Describe(eventhandler.HOLD) != eventhandler.DEFAULT_BINDINGS.Describe(eventhandler.HOLD) {
+		t.Errorf("A missing file should give the default controls, found %v", err)
+		t.Fail()
+	}
+}
+
+func TestVersusPlayerKeepsItsOwnDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "controls_player2.json")
+	screen := New(eventhandler.VERSUS_BINDINGS[1], eventhandler.VERSUS_BINDINGS[1])
+	screen.Clear()
+	screen.BindKey(rl.KeyK)
+
+	if err := screen.Bindings.Save(path); err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	loaded, err := eventhandler.LoadBindings(path, eventhandler.VERSUS_BINDINGS[1])
+	if err != nil || loaded.Describe(eventhandler.MOVE_LEFT) != "K" {
+		t.Errorf("The second player should move left with K, found %s", loaded.Describe(eventhandler.MOVE_LEFT))
+		t.FailNow()
+	}
+
+	screen.Reset()
+	if screen.Bindings.Describe(eventhandler.MOVE_LEFT) != eventhandler.VERSUS_BINDINGS[1].Describe(eventhandler.MOVE_LEFT) {
+		t.Errorf("Resetting should go back to the arrow keys of the second player, found %s", screen.Bindings.Describe(eventhandler.MOVE_LEFT))
+		t.Fail()
+	}
+}
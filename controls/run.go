@@ -0,0 +1,51 @@
+package controls
+
+import (
+	"fmt"
+	eventhandler "tetris/event_handler"
+	renderer "tetris/ui"
+)
+
+// Rebinds the actions in a window that is already open, F2 saves to path. Returns the bindings once the
+// player goes back, false when the window got closed instead.
+func Run(r renderer.Renderer, s *Screen, path string) (eventhandler.Bindings, bool) {
+	status := fmt.Sprintf("Editing %s", path)
+
+	for !r.ShouldClose() {
+		event := eventhandler.HandleControlsEvent(0)
+
+		if s.Capturing && event.Key != 0 {
+			s.BindKey(event.Key)
+		} else if s.Capturing && event.Button != 0 {
+			s.BindButton(event.Button)
+		} else if !s.Capturing {
+			if event.Menu.Back {
+				return s.Bindings, true
+			}
+
+			s.Move(event.Menu.Move)
+			s.Capturing = event.Menu.Select
+
+			if event.Clear {
+				s.Clear()
+			}
+
+			if event.Reset {
+				s.Reset()
+				status = "Back to the default controls"
+			}
+
+			if event.Save {
+				status = fmt.Sprintf("Saved to %s", path)
+				if err := s.Bindings.Save(path); err != nil {
+					status = err.Error()
+				}
+			}
+		}
+
+		options := append(s.options(), "", "enter bind, delete clear, F5 defaults, F2 save, backspace back", status)
+		r.RenderMenu("Controls", options, s.Selected)
+	}
+
+	return s.Bindings, false
+}
@@ -0,0 +1,197 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"tetris/board"
+	"tetris/entity"
+	"tetris/game"
+	"tetris/puzzle"
+)
+
+const (
+	NO_PIECE = -1
+)
+
+// Everything that makes up a puzzle or practice board while it is being built. The brush is the piece type
+// painted cells get, garbage included. The first piece of the sequence is the one the game starts with,
+// the others are the queue.
+type Editor struct {
+	Name        string
+	Rules       string
+	Board       board.Board
+	Brush       int
+	Sequence    []int
+	Hold        int
+	HoldAllowed bool
+	Goal        puzzle.Goal
+}
+
+func New(rulesName string) (Editor, error) {
+	rules, ok := game.RULE_SETS[rulesName]
+	if !ok {
+		return Editor{}, errors.New(fmt.Sprintf("Unknown rule set %s", rulesName))
+	}
+
+	return Editor{
+		Name:        "Custom board",
+		Rules:       rulesName,
+		Board:       board.New(rules.MaxWidth+1, rules.MaxHeight+1),
+		Brush:       board.GARBAGE,
+		Sequence:    make([]int, 0),
+		Hold:        NO_PIECE,
+		HoldAllowed: true,
+		Goal:        puzzle.Goal{Type: puzzle.GOAL_PRACTICE, Count: 1},
+	}, nil
+}
+
+// Picks up a saved puzzle to keep working on it
+func FromPuzzle(p puzzle.Puzzle) (Editor, error) {
+	initialBoard, err := p.InitialBoard()
+	if err != nil {
+		return Editor{}, err
+	}
+
+	e := Editor{
+		Name:        p.Name,
+		Rules:       p.Rules,
+		Board:       initialBoard,
+		Brush:       board.GARBAGE,
+		Sequence:    make([]int, len(p.Sequence)),
+		Hold:        NO_PIECE,
+		HoldAllowed: p.Hold,
+		Goal:        p.Goal,
+	}
+
+	for i, piece := range p.Sequence {
+		e.Sequence[i] = puzzle.PIECES[strings.ToUpper(piece)]
+	}
+
+	if p.HoldPiece != "" {
+		e.Hold = puzzle.PIECES[strings.ToUpper(p.HoldPiece)]
+	}
+
+	return e, nil
+}
+
+func (e *Editor) Paint(x, y int) {
+	e.Board.Set(x, y, board.PieceColor(e.Brush), e.Brush)
+}
+
+func (e *Editor) Erase(x, y int) {
+	e.Board.Set(x, y, board.EMPTY, board.EMPTY)
+}
+
+func (e *Editor) Clear() {
+	e.Board = board.New(e.Board.Width, e.Board.Height)
+}
+
+func (e *Editor) SelectBrush(piece int) {
+	if _, ok := board.PIECE_LETTERS[piece]; ok {
+		e.Brush = piece
+	}
+}
+
+func (e *Editor) QueuePiece(piece int) {
+	e.Sequence = append(e.Sequence, piece)
+}
+
+// Replaces the piece the game starts with, the queue stays as it is
+func (e *Editor) SetStartPiece(piece int) {
+	if len(e.Sequence) == 0 {
+		e.Sequence = append(e.Sequence, piece)
+		return
+	}
+
+	e.Sequence[0] = piece
+}
+
+func (e *Editor) RemovePiece() {
+	if len(e.Sequence) > 0 {
+		e.Sequence = e.Sequence[:len(e.Sequence)-1]
+	}
+}
+
+// Goes from no held piece through every piece and back to none
+func (e *Editor) CycleHold() {
+	e.Hold += 1
+	if e.Hold > entity.Z {
+		e.Hold = NO_PIECE
+	}
+}
+
+func (e *Editor) CycleGoal() {
+	next := (slices.Index(puzzle.GOALS, e.Goal.Type) + 1) % len(puzzle.GOALS)
+	e.Goal.Type = puzzle.GOALS[next]
+}
+
+func (e *Editor) ChangeGoalCount(delta int) {
+	e.Goal.Count = max(1, e.Goal.Count+delta)
+}
+
+func (e Editor) Puzzle() puzzle.Puzzle {
+	p := puzzle.Puzzle{
+		Name:     e.Name,
+		Rules:    e.Rules,
+		Board:    e.Board.StackRows(),
+		Sequence: make([]string, len(e.Sequence)),
+		Hold:     e.HoldAllowed,
+		Goal:     e.Goal,
+	}
+
+	for i, piece := range e.Sequence {
+		p.Sequence[i] = pieceName(piece)
+	}
+
+	if e.Hold != NO_PIECE {
+		p.HoldPiece = pieceName(e.Hold)
+	}
+
+	return p
+}
+
+// Blocks of the board and their colors the way the renderer wants them, colors indexed by x first
+func (e Editor) blocks() ([][2]float32, [][]int) {
+	blocks := make([][2]float32, 0)
+	colors := make([][]int, e.Board.Width)
+
+	for x := range e.Board.Width {
+		colors[x] = make([]int, e.Board.Height)
+		for y := range e.Board.Height {
+			if e.Board.Occupied(x, y) {
+				blocks = append(blocks, [2]float32{float32(x), float32(y)})
+				colors[x][y] = e.Board.Cells[y][x]
+			}
+		}
+	}
+
+	return blocks, colors
+}
+
+func (e Editor) hud(status string) []string {
+	hold := "none"
+	if e.Hold != NO_PIECE {
+		hold = pieceName(e.Hold)
+	}
+	if !e.HoldAllowed {
+		hold += " (disabled)"
+	}
+
+	pieces := make([]string, len(e.Sequence))
+	for i, piece := range e.Sequence {
+		pieces[i] = pieceName(piece)
+	}
+
+	return []string{
+		fmt.Sprintf("Brush: %c  Hold: %s  Goal: %s", board.PIECE_LETTERS[e.Brush], hold, e.Goal.Description()),
+		fmt.Sprintf("Pieces: %s", strings.Join(pieces, " ")),
+		"1-8 brush, IJLOSTZ queue (shift: start), tab/h hold, g/+/- goal, F2 save, enter play",
+		status,
+	}
+}
+
+func pieceName(piece int) string {
+	return string(board.PIECE_LETTERS[piece])
+}
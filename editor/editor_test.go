@@ -0,0 +1,96 @@
+package editor
+
+import (
+	"path/filepath"
+	"testing"
+	"tetris/entity"
+	"tetris/puzzle"
+)
+
+func TestPaintAndErase(t *testing.T) {
+	e, _ := New(puzzle.DEFAULT_RULES)
+	e.SelectBrush(entity.J)
+	e.Paint(0, 19)
+	e.Paint(1, 19)
+	e.Erase(1, 19)
+	e.Paint(-1, 30)
+
+	if e.Board.Piece(0, 19) != entity.J || e.Board.Occupied(1, 19) {
+		t.Errorf("Expected a single J cell, found %s", e.Board.Rows()[19])
+		t.Fail()
+	}
+
+	if rows := e.Puzzle().Board; len(rows) != 1 || rows[0] != "J........." {
+		t.Errorf("Expected only the bottom row to be saved, found %v", rows)
+		t.Fail()
+	}
+}
+
+func TestSaveAndLoadAgain(t *testing.T) {
+	e, _ := New(puzzle.DEFAULT_RULES)
+	for x := range 9 {
+		e.Paint(x, 19)
+	}
+	e.QueuePiece(entity.O)
+	e.QueuePiece(entity.I)
+	e.SetStartPiece(entity.T)
+	e.CycleHold()
+	e.CycleGoal()
+	e.ChangeGoalCount(-5)
+
+	path := filepath.Join(t.TempDir(), "board.json")
+	if err := e.save(path); err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	p, err := puzzle.Load(path)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	loaded, err := FromPuzzle(p)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if loaded.Hold != entity.I || loaded.Goal != (puzzle.Goal{Type: puzzle.GOAL_LINES, Count: 1}) {
+		t.Errorf("Expected hold I and a single line to clear, found hold %d and goal %v", loaded.Hold, loaded.Goal)
+		t.Fail()
+	}
+
+	if len(loaded.Sequence) != 2 || loaded.Sequence[0] != entity.T || loaded.Sequence[1] != entity.I {
+		t.Errorf("Expected the sequence T I, found %v", loaded.Sequence)
+		t.Fail()
+	}
+
+	for y, row := range loaded.Board.Cells {
+		for x := range row {
+			if loaded.Board.Piece(x, y) != e.Board.Piece(x, y) {
+				t.Errorf("Cell x: %d y: %d changed after loading", x, y)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestPlayTheEditedBoard(t *testing.T) {
+	e, _ := New(puzzle.DEFAULT_RULES)
+	e.SelectBrush(entity.L)
+	e.Paint(3, 19)
+	e.SetStartPiece(entity.S)
+
+	tetrisGame, err := e.Puzzle().NewGame(1)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+	tetrisGame.Start()
+
+	if tetrisGame.Board().Piece(3, 19) != entity.L || len(tetrisGame.CollisionDetector.GetAllBlocks()) != 1 {
+		t.Error("Game should start with the painted cell")
+		t.Fail()
+	}
+}
@@ -0,0 +1,104 @@
+package editor
+
+import (
+	"fmt"
+	eventhandler "tetris/event_handler"
+	"tetris/puzzle"
+	renderer "tetris/ui"
+	"time"
+)
+
+// Edits the board in a window that is already open, enter plays the edited state and comes back to the
+// editor afterwards. F2 saves to path. Returns once the window got closed.
+func Run(r renderer.Renderer, e *Editor, path string) {
+	status := fmt.Sprintf("Editing %s", path)
+
+	for !r.ShouldClose() {
+		event := eventhandler.HandleEditorEvent()
+		status = e.apply(event, r, status)
+
+		if event.Save {
+			status = fmt.Sprintf("Saved to %s", path)
+			if err := e.save(path); err != nil {
+				status = err.Error()
+			}
+		}
+
+		if event.Play {
+			p := e.Puzzle()
+			if err := p.Validate(); err != nil {
+				status = err.Error()
+			} else {
+				tetrisGame, err := p.NewGame(time.Now().Unix())
+				if err != nil {
+					status = err.Error()
+				} else {
+					tetrisGame.Renderer = r
+					if !puzzle.Run(&tetrisGame, p) {
+						return
+					}
+				}
+			}
+		}
+
+		blocks, colors := e.blocks()
+		r.BeginFrame()
+		r.DrawBoard(blocks, colors, nil, -1)
+		r.RenderHud(e.hud(status))
+		r.EndFrame()
+	}
+}
+
+func (e *Editor) apply(event eventhandler.EditorEvent, r renderer.Renderer, status string) string {
+	if x, y, ok := r.CellAt(event.MouseX, event.MouseY); ok && event.Paint {
+		e.Paint(x, y)
+	} else if ok && event.Erase {
+		e.Erase(x, y)
+	}
+
+	if event.Brush >= 0 {
+		e.SelectBrush(event.Brush)
+	}
+
+	if event.Piece >= 0 && event.StartPiece {
+		e.SetStartPiece(event.Piece)
+	} else if event.Piece >= 0 {
+		e.QueuePiece(event.Piece)
+	}
+
+	if event.RemovePiece {
+		e.RemovePiece()
+	}
+
+	if event.CycleHold {
+		e.CycleHold()
+	}
+
+	if event.ToggleHold {
+		e.HoldAllowed = !e.HoldAllowed
+	}
+
+	if event.CycleGoal {
+		e.CycleGoal()
+	}
+
+	if event.GoalCount != 0 {
+		e.ChangeGoalCount(event.GoalCount)
+	}
+
+	if event.Clear {
+		e.Clear()
+		status = "Cleared the board"
+	}
+
+	return status
+}
+
+func (e Editor) save(path string) error {
+	p := e.Puzzle()
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	return p.Save(path)
+}
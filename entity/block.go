@@ -25,8 +25,23 @@ const (
 	BLUE   = 1
 	YELLOW = 2
 	GREEN  = 3
+	GRAY   = 4
+	CYAN   = 5
+	ORANGE = 6
+	PURPLE = 7
 )
 
+// Guideline color of every block type, blocks spawn with it
+var PIECE_COLORS map[int]int = map[int]int{
+	I: CYAN,
+	J: BLUE,
+	L: ORANGE,
+	O: YELLOW,
+	S: GREEN,
+	T: PURPLE,
+	Z: RED,
+}
+
 var BLOCK_OCCUPYING_LOCATION map[int]matrix.Matrix = map[int]matrix.Matrix{
 	I: [][]int{{0, 0}, {1, 0}, {2, 0}, {3, 0}},
 	J: [][]int{{0, 0}, {0, 1}, {1, 1}, {2, 1}},
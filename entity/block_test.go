@@ -7,7 +7,7 @@ import (
 )
 
 func TestNew(t *testing.T) {
-	block, err := New(I, [2]int{1, 0})
+	block, err := New(I, RED, [2]int{1, 0})
 	expectedPosition := matrix.Matrix{{1, 0}, {2, 0}, {3, 0}, {4, 0}}
 
 	if err != nil {
@@ -22,7 +22,7 @@ func TestNew(t *testing.T) {
 }
 
 func TestMoveBlock(t *testing.T) {
-	block, err := New(I, [2]int{0, 0})
+	block, err := New(I, RED, [2]int{0, 0})
 	expectedPosition := matrix.Matrix{{1, 0}, {2, 0}, {3, 0}, {4, 0}}
 
 	if err != nil {
@@ -30,7 +30,7 @@ func TestMoveBlock(t *testing.T) {
 		t.Fail()
 	}
 
-	block.MoveBlock([2]float64{1, 0})
+	block.MoveBlock([2]int{1, 0})
 
 	if !block.OccupiedPosition.Equal(expectedPosition) {
 		t.Error("Moved block is not equal with the expected position")
@@ -39,7 +39,7 @@ func TestMoveBlock(t *testing.T) {
 }
 
 func TestRotateBlock(t *testing.T) {
-	block, err := New(Z, [2]int{0, 0})
+	block, err := New(Z, RED, [2]int{0, 0})
 	currentPosition := matrix.Copy(block.OccupiedPosition)
 
 	if err != nil {
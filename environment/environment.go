@@ -0,0 +1,251 @@
+package environment
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	"tetris/matrix"
+	movegenerator "tetris/move_generator"
+)
+
+const (
+	ACTION_INPUT     = "input"
+	ACTION_PLACEMENT = "placement"
+)
+
+const (
+	// a placement that takes longer than this is considered stuck
+	MAX_TICKS_PER_PLACEMENT = 10000
+	LOSE_REWARD             = -1
+)
+
+type Piece struct {
+	Type  int     `json:"type"`
+	Color int     `json:"color"`
+	Cells [][]int `json:"cells"`
+}
+
+type Stats struct {
+	Score  int `json:"score"`
+	Level  int `json:"level"`
+	Lines  int `json:"lines"`
+	Pieces int `json:"pieces"`
+	Ticks  int `json:"ticks"`
+}
+
+type Observation struct {
+	Board      [][]int   `json:"board"` // rows from top to bottom, -1 for empty cells
+	Current    *Piece    `json:"current"`
+	Next       []int     `json:"next"`
+	Hold       int       `json:"hold"` // -1 when nothing is held
+	Placements [][][]int `json:"placements"`
+	Stats      Stats     `json:"stats"`
+}
+
+// Either a raw input for a single tick, or the index of one of the observed placements
+type Action struct {
+	Type      string `json:"type"`
+	Move      int    `json:"move"`
+	Rotate    int    `json:"rotate"`
+	Hold      bool   `json:"hold"`
+	Placement int    `json:"placement"`
+}
+
+var ErrNotReset error = errors.New("Environment has not been reset yet")
+
+type Info struct {
+	LinesCleared int    `json:"lines_cleared"`
+	Ticks        int    `json:"ticks"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Gym style wrapper around a headless game
+type Environment struct {
+	Width      int
+	Height     int
+	game       game.TetrisGame
+	placements []movegenerator.Placement
+}
+
+func New(width, height int) *Environment {
+	return &Environment{Width: width, Height: height}
+}
+
+func (e *Environment) Reset(seed int64) Observation {
+	e.game = game.NewHeadless(e.Width, e.Height, seed)
+	e.game.Start()
+	e.advanceToNextBlock()
+
+	return e.observe()
+}
+
+// Actions that can't be taken end up in the error of the info, the game stays as it was
+func (e *Environment) Step(action Action) (Observation, float64, bool, Info) {
+	if !e.started() {
+		return Observation{Hold: -1}, 0, false, Info{Error: ErrNotReset.Error()}
+	}
+
+	previousScore, previousLines, previousTicks := e.game.Score, e.game.Lines, e.game.Ticks
+	var err error
+
+	if e.game.State == game.LOSE {
+		err = errors.New("Game is already over, reset the environment first")
+	} else if action.Type == ACTION_INPUT {
+		e.game.Update(eventhandler.UpdateEvent{MovingDirection: action.Move, RotateDirection: action.Rotate, Hold: action.Hold})
+	} else if action.Type == ACTION_PLACEMENT {
+		err = e.place(action.Placement)
+	} else {
+		err = errors.New(fmt.Sprintf("Unknown action type %s", action.Type))
+	}
+
+	info := Info{LinesCleared: e.game.Lines - previousLines, Ticks: e.game.Ticks - previousTicks}
+	if err != nil {
+		info.Error = err.Error()
+	}
+
+	done := e.game.State == game.LOSE
+	reward := float64(e.game.Score - previousScore)
+	if done {
+		reward += LOSE_REWARD
+	}
+
+	return e.observe(), reward, done, info
+}
+
+// There is no game before the first reset
+func (e *Environment) started() bool {
+	return e.game.State != 0
+}
+
+// Drives the current block onto the placement and lets the game run until the next block is controllable
+func (e *Environment) place(index int) error {
+	if index < 0 || index >= len(e.placements) {
+		return errors.New(fmt.Sprintf("Placement index %d out of range, there are %d placements", index, len(e.placements)))
+	}
+
+	currentBlock := e.game.CurrentBlock
+	follower, err := movegenerator.NewFollower(e.game.Board(), *currentBlock, e.placements[index].Position)
+	if err != nil {
+		return err
+	}
+
+	for ticks := 0; e.game.CurrentBlock == currentBlock && e.game.State == game.PLAY; ticks++ {
+		if ticks > MAX_TICKS_PER_PLACEMENT {
+			return errors.New("Placement is taking too long, the block is probably stuck")
+		}
+
+		event, err := follower.NextEvent(e.game.Board(), *e.game.CurrentBlock)
+		if err != nil {
+			event = eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN}
+		}
+		e.game.Update(event)
+	}
+
+	e.advanceToNextBlock()
+	return nil
+}
+
+// Spawning and locking happen on ticks where inputs are ignored, no point in asking the agent for those
+func (e *Environment) advanceToNextBlock() {
+	for e.game.State == game.PLAY && e.game.BlockState != game.MOVING_BLOCK {
+		e.game.Update(eventhandler.UpdateEvent{})
+	}
+}
+
+func (e *Environment) observe() Observation {
+	observation := Observation{
+		Board:      e.game.Board().Cells,
+		Next:       make([]int, 0, len(e.game.NextBlocks)),
+		Hold:       -1,
+		Placements: make([][][]int, 0),
+		Stats: Stats{
+			Score:  e.game.Score,
+			Level:  e.game.Level,
+			Lines:  e.game.Lines,
+			Pieces: e.game.Pieces,
+			Ticks:  e.game.Ticks,
+		},
+	}
+
+	for _, block := range e.game.NextBlocks {
+		observation.Next = append(observation.Next, block.EntityType)
+	}
+
+	if e.game.HoldBlock != nil {
+		observation.Hold = e.game.HoldBlock.EntityType
+	}
+
+	e.placements = nil
+	if e.game.CurrentBlock != nil && e.game.State == game.PLAY {
+		observation.Current = &Piece{
+			Type:  e.game.CurrentBlock.EntityType,
+			Color: e.game.CurrentBlock.Color,
+			Cells: matrix.Copy(e.game.CurrentBlock.OccupiedPosition),
+		}
+
+		e.placements = movegenerator.Generate(e.game.Board(), *e.game.CurrentBlock)
+		for _, placement := range e.placements {
+			observation.Placements = append(observation.Placements, matrix.Copy(placement.Position))
+		}
+	}
+
+	return observation
+}
+
+type request struct {
+	Command string `json:"command"`
+	Seed    int64  `json:"seed"`
+	Action  Action `json:"action"`
+}
+
+type response struct {
+	Observation *Observation `json:"observation,omitempty"`
+	Reward      float64      `json:"reward"`
+	Done        bool         `json:"done"`
+	Info        *Info        `json:"info,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// Speaks a JSON lines protocol, one request per line and one response per line:
+//
+//	{"command": "reset", "seed": 42}
+//	{"command": "step", "action": {"type": "placement", "placement": 3}}
+//	{"command": "close"}
+func (e *Environment) Serve(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		var req request
+		var res response
+
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			res.Error = err.Error()
+		} else if req.Command == "reset" {
+			observation := e.Reset(req.Seed)
+			res.Observation = &observation
+		} else if req.Command == "step" {
+			if !e.started() {
+				res.Error = ErrNotReset.Error()
+			} else {
+				observation, reward, done, info := e.Step(req.Action)
+				res = response{Observation: &observation, Reward: reward, Done: done, Info: &info}
+			}
+		} else if req.Command == "close" {
+			return nil
+		} else {
+			res.Error = fmt.Sprintf("Unknown command %s", req.Command)
+		}
+
+		if err := encoder.Encode(res); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,115 @@
+package environment
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResetShouldBeDeterministic(t *testing.T) {
+	first := New(9, 19).Reset(7)
+	second := New(9, 19).Reset(7)
+
+	if first.Current == nil || second.Current == nil {
+		t.Error("Observation after reset should have a current block")
+		t.FailNow()
+	}
+
+	if first.Current.Type != second.Current.Type || len(first.Next) != len(second.Next) {
+		t.Errorf("Same seed should give the same blocks, found %d and %d", first.Current.Type, second.Current.Type)
+		t.Fail()
+	}
+
+	for i := range first.Next {
+		if first.Next[i] != second.Next[i] {
+			t.Errorf("Next block %d differs, found %d and %d", i, first.Next[i], second.Next[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestStepPlacementShouldLockBlock(t *testing.T) {
+	env := New(9, 19)
+	observation := env.Reset(7)
+
+	if len(observation.Placements) == 0 {
+		t.Error("Observation should list the placements of the current block")
+		t.FailNow()
+	}
+
+	observation, _, done, info := env.Step(Action{Type: ACTION_PLACEMENT, Placement: 0})
+
+	if info.Error != "" {
+		t.Error(info.Error)
+		t.FailNow()
+	}
+
+	if done || observation.Stats.Pieces != 1 {
+		t.Errorf("One block should have been placed, found %d", observation.Stats.Pieces)
+		t.Fail()
+	}
+
+	filledCells := 0
+	for _, row := range observation.Board {
+		for _, cell := range row {
+			if cell != -1 {
+				filledCells += 1
+			}
+		}
+	}
+
+	if filledCells != 4 {
+		t.Errorf("Board should have the 4 cells of the placed block, found %d", filledCells)
+		t.Fail()
+	}
+}
+
+func TestStepBeforeReset(t *testing.T) {
+	_, _, done, info := New(9, 19).Step(Action{Type: ACTION_PLACEMENT, Placement: 0})
+
+	if info.Error != ErrNotReset.Error() || done {
+		t.Errorf("Stepping before reset should fail, found error %q", info.Error)
+		t.Fail()
+	}
+}
+
+func TestServe(t *testing.T) {
+	in := strings.NewReader(strings.Join([]string{
+		`{"command": "step"}`,
+		`{"command": "reset", "seed": 3}`,
+		`{"command": "step", "action": {"type": "input", "move": 1}}`,
+		`{"command": "close"}`,
+		`{"command": "reset", "seed": 3}`,
+	}, "\n"))
+	var out strings.Builder
+
+	err := New(9, 19).Serve(in, &out)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	responses := make([]response, 0)
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var res response
+		json.Unmarshal(scanner.Bytes(), &res)
+		responses = append(responses, res)
+	}
+
+	if len(responses) != 3 {
+		t.Errorf("Should respond to every request until close, found %d responses", len(responses))
+		t.FailNow()
+	}
+
+	if responses[0].Error == "" {
+		t.Error("Stepping before reset should fail")
+		t.Fail()
+	}
+
+	if responses[2].Observation == nil || responses[2].Info.Ticks != 1 {
+		t.Error("Input action should advance the game by a single tick")
+		t.Fail()
+	}
+}
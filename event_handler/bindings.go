@@ -0,0 +1,420 @@
+package eventhandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"tetris/entity"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Logical actions of a player, the names are the ones used in the controls file
+const (
+	MOVE_LEFT             = "move_left"
+	MOVE_RIGHT            = "move_right"
+	SOFT_DROP             = "soft_drop"
+	HARD_DROP             = "hard_drop"
+	ROTATE_CLOCKWISE      = "rotate_clockwise"
+	ROTATE_ANTI_CLOCKWISE = "rotate_anti_clockwise"
+	HOLD                  = "hold"
+	PAUSE                 = "pause"
+)
+
+// Every action in the order the controls screen lists them
+var ACTIONS []string = []string{MOVE_LEFT, MOVE_RIGHT, SOFT_DROP, HARD_DROP, ROTATE_CLOCKWISE, ROTATE_ANTI_CLOCKWISE, HOLD, PAUSE}
+
+// Keys and gamepad buttons that trigger an action, any of them does
+type Binding struct {
+	Keys    []int32
+	Buttons []int32
+}
+
+type Bindings map[string]Binding
+
+var DEFAULT_BINDINGS Bindings = Bindings{
+	MOVE_LEFT:             {Keys: []int32{rl.KeyA, rl.KeyLeft}, Buttons: []int32{rl.GamepadButtonLeftFaceLeft}},
+	MOVE_RIGHT:            {Keys: []int32{rl.KeyD, rl.KeyRight}, Buttons: []int32{rl.GamepadButtonLeftFaceRight}},
+	SOFT_DROP:             {Keys: []int32{rl.KeyS, rl.KeyDown}, Buttons: []int32{rl.GamepadButtonLeftFaceDown}},
+	HARD_DROP:             {Keys: []int32{rl.KeySpace}, Buttons: []int32{rl.GamepadButtonLeftFaceUp}},
+	ROTATE_CLOCKWISE:      {Keys: []int32{rl.KeyR, rl.KeyUp}, Buttons: []int32{rl.GamepadButtonRightFaceDown}},
+	ROTATE_ANTI_CLOCKWISE: {Keys: []int32{rl.KeyL}, Buttons: []int32{rl.GamepadButtonRightFaceLeft}},
+	HOLD:                  {Keys: []int32{rl.KeyC}, Buttons: []int32{rl.GamepadButtonLeftTrigger1, rl.GamepadButtonRightTrigger1}},
+	PAUSE:                 {Keys: []int32{rl.KeyP}, Buttons: []int32{rl.GamepadButtonMiddleRight}},
+}
+
+// Two players on one keyboard, each of them can also use a gamepad. Pausing only one of the boards makes no sense
+var VERSUS_BINDINGS []Bindings = []Bindings{
+	{
+		MOVE_LEFT:             {Keys: []int32{rl.KeyA}, Buttons: []int32{rl.GamepadButtonLeftFaceLeft}},
+		MOVE_RIGHT:            {Keys: []int32{rl.KeyD}, Buttons: []int32{rl.GamepadButtonLeftFaceRight}},
+		SOFT_DROP:             {Keys: []int32{rl.KeyS}, Buttons: []int32{rl.GamepadButtonLeftFaceDown}},
+		HARD_DROP:             {Keys: []int32{rl.KeyLeftShift}, Buttons: []int32{rl.GamepadButtonLeftFaceUp}},
+		ROTATE_CLOCKWISE:      {Keys: []int32{rl.KeyW}, Buttons: []int32{rl.GamepadButtonRightFaceDown}},
+		ROTATE_ANTI_CLOCKWISE: {Keys: []int32{rl.KeyQ}, Buttons: []int32{rl.GamepadButtonRightFaceLeft}},
+		HOLD:                  {Keys: []int32{rl.KeyE}, Buttons: []int32{rl.GamepadButtonLeftTrigger1}},
+	},
+	{
+		MOVE_LEFT:             {Keys: []int32{rl.KeyLeft}, Buttons: []int32{rl.GamepadButtonLeftFaceLeft}},
+		MOVE_RIGHT:            {Keys: []int32{rl.KeyRight}, Buttons: []int32{rl.GamepadButtonLeftFaceRight}},
+		SOFT_DROP:             {Keys: []int32{rl.KeyDown}, Buttons: []int32{rl.GamepadButtonLeftFaceDown}},
+		HARD_DROP:             {Keys: []int32{rl.KeyEnter}, Buttons: []int32{rl.GamepadButtonLeftFaceUp}},
+		ROTATE_CLOCKWISE:      {Keys: []int32{rl.KeyUp}, Buttons: []int32{rl.GamepadButtonRightFaceDown}},
+		ROTATE_ANTI_CLOCKWISE: {Keys: []int32{rl.KeyRightControl}, Buttons: []int32{rl.GamepadButtonRightFaceLeft}},
+		HOLD:                  {Keys: []int32{rl.KeyRightShift}, Buttons: []int32{rl.GamepadButtonLeftTrigger1}},
+	},
+}
+
+// Names of the keys that don't print as a single character
+var KEY_NAMES map[int32]string = map[int32]string{
+	rl.KeySpace:        "Space",
+	rl.KeyEscape:       "Escape",
+	rl.KeyEnter:        "Enter",
+	rl.KeyTab:          "Tab",
+	rl.KeyBackspace:    "Backspace",
+	rl.KeyInsert:       "Insert",
+	rl.KeyDelete:       "Delete",
+	rl.KeyRight:        "Right",
+	rl.KeyLeft:         "Left",
+	rl.KeyDown:         "Down",
+	rl.KeyUp:           "Up",
+	rl.KeyPageUp:       "PageUp",
+	rl.KeyPageDown:     "PageDown",
+	rl.KeyHome:         "Home",
+	rl.KeyEnd:          "End",
+	rl.KeyLeftShift:    "LeftShift",
+	rl.KeyLeftControl:  "LeftControl",
+	rl.KeyLeftAlt:      "LeftAlt",
+	rl.KeyRightShift:   "RightShift",
+	rl.KeyRightControl: "RightControl",
+	rl.KeyRightAlt:     "RightAlt",
+	rl.KeyF1:           "F1",
+	rl.KeyF2:           "F2",
+	rl.KeyF3:           "F3",
+	rl.KeyF4:           "F4",
+	rl.KeyF5:           "F5",
+	rl.KeyF6:           "F6",
+	rl.KeyF7:           "F7",
+	rl.KeyF8:           "F8",
+	rl.KeyF9:           "F9",
+	rl.KeyF10:          "F10",
+	rl.KeyF11:          "F11",
+	rl.KeyF12:          "F12",
+	rl.KeyKp0:          "Keypad0",
+	rl.KeyKp1:          "Keypad1",
+	rl.KeyKp2:          "Keypad2",
+	rl.KeyKp3:          "Keypad3",
+	rl.KeyKp4:          "Keypad4",
+	rl.KeyKp5:          "Keypad5",
+	rl.KeyKp6:          "Keypad6",
+	rl.KeyKp7:          "Keypad7",
+	rl.KeyKp8:          "Keypad8",
+	rl.KeyKp9:          "Keypad9",
+	rl.KeyKpEnter:      "KeypadEnter",
+}
+
+var BUTTON_NAMES map[int32]string = map[int32]string{
+	rl.GamepadButtonLeftFaceUp:     "DpadUp",
+	rl.GamepadButtonLeftFaceRight:  "DpadRight",
+	rl.GamepadButtonLeftFaceDown:   "DpadDown",
+	rl.GamepadButtonLeftFaceLeft:   "DpadLeft",
+	rl.GamepadButtonRightFaceUp:    "FaceUp",
+	rl.GamepadButtonRightFaceRight: "FaceRight",
+	rl.GamepadButtonRightFaceDown:  "FaceDown",
+	rl.GamepadButtonRightFaceLeft:  "FaceLeft",
+	rl.GamepadButtonLeftTrigger1:   "LeftBumper",
+	rl.GamepadButtonLeftTrigger2:   "LeftTrigger",
+	rl.GamepadButtonRightTrigger1:  "RightBumper",
+	rl.GamepadButtonRightTrigger2:  "RightTrigger",
+	rl.GamepadButtonMiddleLeft:     "Select",
+	rl.GamepadButtonMiddleRight:    "Start",
+	rl.GamepadButtonLeftThumb:      "LeftStick",
+	rl.GamepadButtonRightThumb:     "RightStick",
+}
+
+// Letters, digits and punctuation go by the character they print, the others by KEY_NAMES
+func KeyName(key int32) string {
+	if name, ok := KEY_NAMES[key]; ok {
+		return name
+	}
+
+	if key > rl.KeySpace && key <= rl.KeyGrave {
+		return string(rune(key))
+	}
+
+	return fmt.Sprintf("Key%d", key)
+}
+
+func keyOf(name string) (int32, bool) {
+	for key, keyName := range KEY_NAMES {
+		if strings.EqualFold(keyName, name) {
+			return key, true
+		}
+	}
+
+	if runes := []rune(strings.ToUpper(name)); len(runes) == 1 && runes[0] > rl.KeySpace && runes[0] <= rl.KeyGrave {
+		return int32(runes[0]), true
+	}
+
+	var key int32
+	if _, err := fmt.Sscanf(name, "Key%d", &key); err == nil {
+		return key, true
+	}
+
+	return 0, false
+}
+
+func ButtonName(button int32) string {
+	if name, ok := BUTTON_NAMES[button]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Button%d", button)
+}
+
+func buttonOf(name string) (int32, bool) {
+	for button, buttonName := range BUTTON_NAMES {
+		if strings.EqualFold(buttonName, name) {
+			return button, true
+		}
+	}
+
+	return 0, false
+}
+
+// Keys and buttons are written by name so the controls file can be edited by hand
+type bindingFile struct {
+	Keys    []string `json:"keys"`
+	Buttons []string `json:"buttons"`
+}
+
+func (b Binding) MarshalJSON() ([]byte, error) {
+	file := bindingFile{Keys: make([]string, len(b.Keys)), Buttons: make([]string, len(b.Buttons))}
+	for i, key := range b.Keys {
+		file.Keys[i] = KeyName(key)
+	}
+	for i, button := range b.Buttons {
+		file.Buttons[i] = ButtonName(button)
+	}
+
+	return json.Marshal(file)
+}
+
+func (b *Binding) UnmarshalJSON(content []byte) error {
+	file := bindingFile{}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return err
+	}
+
+	*b = Binding{Keys: make([]int32, 0), Buttons: make([]int32, 0)}
+	for _, name := range file.Keys {
+		key, ok := keyOf(name)
+		if !ok {
+			return errors.New(fmt.Sprintf("Unknown key %s", name))
+		}
+		b.Keys = append(b.Keys, key)
+	}
+
+	for _, name := range file.Buttons {
+		button, ok := buttonOf(name)
+		if !ok {
+			return errors.New(fmt.Sprintf("Unknown gamepad button %s", name))
+		}
+		b.Buttons = append(b.Buttons, button)
+	}
+
+	return nil
+}
+
+func DefaultBindingsPath() string {
+	configDirectory, err := os.UserConfigDir()
+	if err != nil {
+		return "controls.json"
+	}
+
+	return filepath.Join(configDirectory, "tetris", "controls.json")
+}
+
+// Versus players keep their bindings apart from the ones of a single player, player 1 or 2
+func DefaultVersusBindingsPath(player int) string {
+	name := fmt.Sprintf("controls_player%d.json", player)
+
+	configDirectory, err := os.UserConfigDir()
+	if err != nil {
+		return name
+	}
+
+	return filepath.Join(configDirectory, "tetris", name)
+}
+
+// A missing file or action keeps the given defaults, e.g. DEFAULT_BINDINGS or one of VERSUS_BINDINGS
+func LoadBindings(path string, defaults Bindings) (Bindings, error) {
+	bindings := defaults.Copy()
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return bindings, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	loaded := Bindings{}
+	if err := json.Unmarshal(content, &loaded); err != nil {
+		return nil, err
+	}
+
+	for action, binding := range loaded {
+		if !slices.Contains(ACTIONS, action) {
+			return nil, errors.New(fmt.Sprintf("Unknown action %s in %s", action, path))
+		}
+		bindings[action] = binding
+	}
+
+	return bindings, nil
+}
+
+func (b Bindings) Save(path string) error {
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
+
+func (b Bindings) Copy() Bindings {
+	copied := make(Bindings, len(b))
+	for action, binding := range b {
+		copied[action] = Binding{
+			Keys:    append(make([]int32, 0, len(binding.Keys)), binding.Keys...),
+			Buttons: append(make([]int32, 0, len(binding.Buttons)), binding.Buttons...),
+		}
+	}
+
+	return copied
+}
+
+// Keys and buttons of the action, the way the controls screen shows them
+func (b Bindings) Describe(action string) string {
+	names := make([]string, 0)
+	for _, key := range b[action].Keys {
+		names = append(names, KeyName(key))
+	}
+	for _, button := range b[action].Buttons {
+		names = append(names, ButtonName(button))
+	}
+
+	if len(names) == 0 {
+		return "unbound"
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// Polls the keyboard and one gamepad with the given bindings, held left and right keys repeat with the handling
+type InputHandler struct {
+	Bindings  Bindings
+	Gamepad   int32
+	autoShift AutoShift
+}
+
+func NewInputHandler(bindings Bindings, handling Handling, gamepad int32) *InputHandler {
+	return &InputHandler{Bindings: bindings, Gamepad: gamepad, autoShift: AutoShift{Handling: handling}}
+}
+
+func (h InputHandler) pressed(action string) bool {
+	binding := h.Bindings[action]
+	for _, key := range binding.Keys {
+		if rl.IsKeyPressed(key) {
+			return true
+		}
+	}
+
+	if rl.IsGamepadAvailable(h.Gamepad) {
+		for _, button := range binding.Buttons {
+			if rl.IsGamepadButtonPressed(h.Gamepad, button) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (h InputHandler) down(action string) bool {
+	binding := h.Bindings[action]
+	for _, key := range binding.Keys {
+		if rl.IsKeyDown(key) {
+			return true
+		}
+	}
+
+	if rl.IsGamepadAvailable(h.Gamepad) {
+		for _, button := range binding.Buttons {
+			if rl.IsGamepadButtonDown(h.Gamepad, button) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (h *InputHandler) HandleEvent() UpdateEvent {
+	updateEvent := UpdateEvent{}
+	updateEvent.MovingDirection, updateEvent.ToWall = h.autoShift.Step(h.down(MOVE_LEFT), h.down(MOVE_RIGHT), h.pressed(MOVE_LEFT), h.pressed(MOVE_RIGHT))
+	if updateEvent.MovingDirection == 0 && h.down(SOFT_DROP) {
+		updateEvent.MovingDirection = DOWN
+	}
+
+	if h.pressed(ROTATE_CLOCKWISE) {
+		updateEvent.RotateDirection = entity.CLOCKWISE
+	} else if h.pressed(ROTATE_ANTI_CLOCKWISE) {
+		updateEvent.RotateDirection = entity.ANTI_CLOCKWISE
+	}
+
+	updateEvent.HardDrop = h.pressed(HARD_DROP)
+	updateEvent.Hold = h.pressed(HOLD)
+	updateEvent.Pause = h.pressed(PAUSE)
+
+	for _, action := range ACTIONS {
+		if action != PAUSE && h.pressed(action) {
+			updateEvent.Presses += 1
+		}
+	}
+
+	return updateEvent
+}
+
+var defaultHandler *InputHandler = NewInputHandler(DEFAULT_BINDINGS, DEFAULT_HANDLING, 0)
+
+// Controls of games that have no event handler of their own, the ones from the controls and handling files usually
+func UseControls(bindings Bindings, handling Handling) {
+	defaultHandler = NewInputHandler(bindings, handling, 0)
+}
+
+// Keeps the handling, e.g. after the bindings got changed on the controls screen
+func UseBindings(bindings Bindings) {
+	defaultHandler.Bindings = bindings
+}
+
+func HandleEvent() UpdateEvent {
+	updateEvent := defaultHandler.HandleEvent()
+	updateEvent.NextTheme = HandleThemeEvent()
+
+	return updateEvent
+}
+
+// Pause key of the default controls, reading it leaves their auto shift alone
+func HandlePauseEvent() bool {
+	return defaultHandler.pressed(PAUSE)
+}
@@ -1,7 +1,6 @@
 package eventhandler
 
 import rl "github.com/gen2brain/raylib-go/raylib"
-import "tetris/entity"
 
 const (
 	LEFT  = 1
@@ -13,25 +12,180 @@ type UpdateEvent struct {
 	MovingDirection int
 	RotateDirection int
 	GameState       int
+	Hold            bool
+	HardDrop        bool
+	ToWall          bool // the block moves left or right as far as it goes
+	Pause           bool // pauses a running game and continues a paused one
+	Presses         int  // keys and buttons pressed down this frame, pausing doesn't count
+	NextTheme       bool // switches the renderer to the next theme, the game plays on
 }
 
-func HandleEvent() UpdateEvent {
-	updateEvent := UpdateEvent{
-		RotateDirection: 0,
+// Anything that can produce the input of a single frame, the keyboard or a bot for example
+type EventHandler interface {
+	HandleEvent() UpdateEvent
+}
+
+type MenuEvent struct {
+	Move   int // -1 for the option above, 1 for the one below
+	Select bool
+	Back   bool
+}
+
+func HandleMenuEvent() MenuEvent {
+	menuEvent := MenuEvent{}
+	if rl.IsKeyPressed(rl.KeyUp) || rl.IsKeyPressed(rl.KeyW) {
+		menuEvent.Move = -1
+	} else if rl.IsKeyPressed(rl.KeyDown) || rl.IsKeyPressed(rl.KeyS) {
+		menuEvent.Move = 1
+	}
+
+	menuEvent.Select = rl.IsKeyPressed(rl.KeyEnter) || rl.IsKeyPressed(rl.KeySpace)
+	menuEvent.Back = rl.IsKeyPressed(rl.KeyBackspace)
+
+	return menuEvent
+}
+
+// Key that switches to the next theme while playing
+func HandleThemeEvent() bool {
+	return rl.IsKeyPressed(rl.KeyF4)
+}
+
+// Keys of the pieces in the editor, in the order of the entity types
+var PIECE_KEYS []int32 = []int32{rl.KeyI, rl.KeyJ, rl.KeyL, rl.KeyO, rl.KeyS, rl.KeyT, rl.KeyZ}
+
+// Keys of the brushes in the editor, the pieces in the order of the entity types and garbage last
+var BRUSH_KEYS []int32 = []int32{rl.KeyOne, rl.KeyTwo, rl.KeyThree, rl.KeyFour, rl.KeyFive, rl.KeySix, rl.KeySeven, rl.KeyEight}
+
+type EditorEvent struct {
+	MouseX      float32
+	MouseY      float32
+	Paint       bool
+	Erase       bool
+	Brush       int // -1 when no brush got picked
+	Piece       int // -1 when no piece got picked
+	StartPiece  bool
+	RemovePiece bool
+	CycleHold   bool
+	ToggleHold  bool
+	CycleGoal   bool
+	GoalCount   int // -1 or 1 to change the count of the goal
+	Clear       bool
+	Save        bool
+	Play        bool
+}
+
+// Left mouse paints and right mouse erases. Letters queue pieces, with shift they replace the starting piece
+func HandleEditorEvent() EditorEvent {
+	mouse := rl.GetMousePosition()
+	editorEvent := EditorEvent{
+		MouseX: mouse.X,
+		MouseY: mouse.Y,
+		Paint:  rl.IsMouseButtonDown(rl.MouseButtonLeft),
+		Erase:  rl.IsMouseButtonDown(rl.MouseButtonRight),
+		Brush:  -1,
+		Piece:  -1,
+	}
+
+	for brush, key := range BRUSH_KEYS {
+		if rl.IsKeyPressed(key) {
+			editorEvent.Brush = brush
+		}
+	}
+
+	for piece, key := range PIECE_KEYS {
+		if rl.IsKeyPressed(key) {
+			editorEvent.Piece = piece
+		}
+	}
+
+	if rl.IsKeyPressed(rl.KeyEqual) {
+		editorEvent.GoalCount = 1
+	} else if rl.IsKeyPressed(rl.KeyMinus) {
+		editorEvent.GoalCount = -1
 	}
-	if rl.IsKeyPressed(rl.KeyA) {
-		updateEvent.MovingDirection = LEFT
-	} else if rl.IsKeyPressed(rl.KeyD) {
-		updateEvent.MovingDirection = RIGHT
-	} else if rl.IsKeyDown(rl.KeyS) {
-		updateEvent.MovingDirection = DOWN
+
+	editorEvent.StartPiece = rl.IsKeyDown(rl.KeyLeftShift) || rl.IsKeyDown(rl.KeyRightShift)
+	editorEvent.RemovePiece = rl.IsKeyPressed(rl.KeyBackspace)
+	editorEvent.CycleHold = rl.IsKeyPressed(rl.KeyTab)
+	editorEvent.ToggleHold = rl.IsKeyPressed(rl.KeyH)
+	editorEvent.CycleGoal = rl.IsKeyPressed(rl.KeyG)
+	editorEvent.Clear = rl.IsKeyPressed(rl.KeyDelete)
+	editorEvent.Save = rl.IsKeyPressed(rl.KeyF2)
+	editorEvent.Play = rl.IsKeyPressed(rl.KeyEnter)
+
+	return editorEvent
+}
+
+type ControlsEvent struct {
+	Menu   MenuEvent
+	Key    int32 // rl.KeyNull when no key got pressed
+	Button int32 // rl.GamepadButtonUnknown when no button of the gamepad got pressed
+	Clear  bool
+	Reset  bool
+	Save   bool
+}
+
+// Menu keys move through the actions, the key or button pressed is reported as well for binding it
+func HandleControlsEvent(gamepad int32) ControlsEvent {
+	controlsEvent := ControlsEvent{
+		Menu:  HandleMenuEvent(),
+		Key:   rl.GetKeyPressed(),
+		Clear: rl.IsKeyPressed(rl.KeyDelete),
+		Reset: rl.IsKeyPressed(rl.KeyF5),
+		Save:  rl.IsKeyPressed(rl.KeyF2),
+	}
+
+	if rl.IsGamepadAvailable(gamepad) {
+		for button := range BUTTON_NAMES {
+			if rl.IsGamepadButtonPressed(gamepad, button) {
+				controlsEvent.Button = button
+			}
+		}
+	}
+
+	return controlsEvent
+}
+
+type MouseEvent struct {
+	X      float32
+	Y      float32
+	Place  bool
+	Rotate int // quarter turns, positive for clockwise
+	Hold   bool
+}
+
+// Buttons pressed and wheel notches turned, the pointer moving around is free
+func (m MouseEvent) Presses() int {
+	presses := max(m.Rotate, -m.Rotate)
+	if m.Place {
+		presses++
+	}
+	if m.Hold {
+		presses++
+	}
+
+	return presses
+}
+
+// Left click places, the wheel and the right button turn the piece, the middle button holds
+func HandleMouseEvent() MouseEvent {
+	mouse := rl.GetMousePosition()
+	mouseEvent := MouseEvent{
+		X:     mouse.X,
+		Y:     mouse.Y,
+		Place: rl.IsMouseButtonPressed(rl.MouseButtonLeft),
+		Hold:  rl.IsMouseButtonPressed(rl.MouseButtonMiddle),
+	}
+
+	if wheel := rl.GetMouseWheelMove(); wheel > 0 {
+		mouseEvent.Rotate = 1
+	} else if wheel < 0 {
+		mouseEvent.Rotate = -1
 	}
 
-	if rl.IsKeyPressed(rl.KeyR) {
-		updateEvent.RotateDirection = entity.CLOCKWISE
-	} else if rl.IsKeyPressed(rl.KeyL) {
-		updateEvent.RotateDirection = entity.ANTI_CLOCKWISE
+	if rl.IsMouseButtonPressed(rl.MouseButtonRight) {
+		mouseEvent.Rotate += 1
 	}
 
-	return updateEvent
+	return mouseEvent
 }
@@ -0,0 +1,125 @@
+package eventhandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// How the pieces react to held keys, counted in ticks. Holding left or right moves once, waits for the
+// delayed auto shift and then repeats every auto repeat rate ticks, a rate of 0 goes straight to the wall.
+// The soft drop factor multiplies the gravity while soft dropping.
+type Handling struct {
+	DelayedAutoShift int `json:"das"`
+	AutoRepeatRate   int `json:"arr"`
+	SoftDropFactor   int `json:"soft_drop_factor"`
+}
+
+// the soft drop factor is the one the game always had
+var DEFAULT_HANDLING Handling = Handling{DelayedAutoShift: 10, AutoRepeatRate: 2, SoftDropFactor: 4}
+
+func (h Handling) Validate() error {
+	if h.DelayedAutoShift < 0 || h.AutoRepeatRate < 0 {
+		return errors.New(fmt.Sprintf("Auto shift delay and repeat rate can't be negative, found %d and %d", h.DelayedAutoShift, h.AutoRepeatRate))
+	}
+
+	if h.SoftDropFactor < 1 {
+		return errors.New(fmt.Sprintf("Soft drop factor should be at least 1, found %d", h.SoftDropFactor))
+	}
+
+	return nil
+}
+
+func DefaultHandlingPath() string {
+	configDirectory, err := os.UserConfigDir()
+	if err != nil {
+		return "handling.json"
+	}
+
+	return filepath.Join(configDirectory, "tetris", "handling.json")
+}
+
+// A missing file or value keeps the default handling
+func LoadHandling(path string) (Handling, error) {
+	handling := DEFAULT_HANDLING
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return handling, nil
+	} else if err != nil {
+		return handling, err
+	}
+
+	if err := json.Unmarshal(content, &handling); err != nil {
+		return handling, err
+	}
+
+	return handling, handling.Validate()
+}
+
+func (h Handling) Save(path string) error {
+	content, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
+
+// Turns held left and right keys into moves tick by tick
+type AutoShift struct {
+	Handling  Handling
+	direction int
+	held      int
+}
+
+// Direction to move in this tick, 0 for none, and whether the block goes all the way to the wall.
+// A freshly pressed direction wins over the one that was held before.
+func (a *AutoShift) Step(leftDown, rightDown, leftPressed, rightPressed bool) (int, bool) {
+	direction := a.direction
+	if leftPressed {
+		direction = LEFT
+	} else if rightPressed {
+		direction = RIGHT
+	} else if direction == LEFT && !leftDown || direction == RIGHT && !rightDown || direction == 0 {
+		// the held key got released, the other one may still be down
+		direction = 0
+		if leftDown {
+			direction = LEFT
+		} else if rightDown {
+			direction = RIGHT
+		}
+	}
+
+	if direction == 0 {
+		a.direction, a.held = 0, 0
+		return 0, false
+	}
+
+	if direction != a.direction || leftPressed || rightPressed {
+		a.direction, a.held = direction, 0
+		return direction, false
+	}
+
+	a.held += 1
+	if a.held < a.Handling.DelayedAutoShift {
+		return 0, false
+	}
+
+	if a.Handling.AutoRepeatRate == 0 {
+		return direction, true
+	}
+
+	if (a.held-a.Handling.DelayedAutoShift)%a.Handling.AutoRepeatRate == 0 {
+		return direction, false
+	}
+
+	return 0, false
+}
@@ -0,0 +1,78 @@
+package eventhandler
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Holds right for the given amount of ticks and collects the direction of every tick
+func holdRight(autoShift *AutoShift, ticks int) []int {
+	moves := make([]int, ticks)
+	for i := range ticks {
+		direction, toWall := autoShift.Step(false, true, false, i == 0)
+		if toWall {
+			direction *= 10
+		}
+		moves[i] = direction
+	}
+
+	return moves
+}
+
+func TestAutoShift(t *testing.T) {
+	autoShift := AutoShift{Handling: Handling{DelayedAutoShift: 3, AutoRepeatRate: 2}}
+	expected := []int{RIGHT, 0, 0, RIGHT, 0, RIGHT, 0, RIGHT}
+
+	if moves := holdRight(&autoShift, len(expected)); !slices.Equal(moves, expected) {
+		t.Errorf("Expected the moves %v, found %v", expected, moves)
+		t.Fail()
+	}
+
+	// pressing left while right is still held turns around right away and starts over
+	if direction, _ := autoShift.Step(true, true, true, false); direction != LEFT {
+		t.Errorf("Pressing left should move left, found %d", direction)
+		t.Fail()
+	}
+
+	if direction, _ := autoShift.Step(false, true, false, false); direction != RIGHT {
+		t.Errorf("Releasing left should go back to the held right, found %d", direction)
+		t.Fail()
+	}
+
+	if direction, _ := autoShift.Step(false, false, false, false); direction != 0 {
+		t.Errorf("Releasing everything should stop moving, found %d", direction)
+		t.Fail()
+	}
+}
+
+func TestAutoShiftToTheWall(t *testing.T) {
+	autoShift := AutoShift{Handling: Handling{DelayedAutoShift: 2, AutoRepeatRate: 0}}
+	expected := []int{RIGHT, 0, RIGHT * 10, RIGHT * 10}
+
+	if moves := holdRight(&autoShift, len(expected)); !slices.Equal(moves, expected) {
+		t.Errorf("Expected the moves %v, found %v", expected, moves)
+		t.Fail()
+	}
+}
+
+func TestLoadHandling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "handling.json")
+
+	if handling, err := LoadHandling(path); err != nil || handling != DEFAULT_HANDLING {
+		t.Errorf("A missing file should give the default handling, found %v", handling)
+		t.Fail()
+	}
+
+	Handling{DelayedAutoShift: 7, AutoRepeatRate: 0, SoftDropFactor: 20}.Save(path)
+	if handling, err := LoadHandling(path); err != nil || handling.DelayedAutoShift != 7 || handling.AutoRepeatRate != 0 || handling.SoftDropFactor != 20 {
+		t.Errorf("Expected the saved handling back, found %v", handling)
+		t.Fail()
+	}
+
+	Handling{SoftDropFactor: 0}.Save(path)
+	if _, err := LoadHandling(path); err == nil {
+		t.Error("Soft drop factor 0 should be rejected")
+		t.Fail()
+	}
+}
@@ -0,0 +1,204 @@
+package finesse
+
+import (
+	"strings"
+	"tetris/board"
+	"tetris/entity"
+	"tetris/game"
+	"tetris/matrix"
+	movegenerator "tetris/move_generator"
+)
+
+// Key presses finesse is counted in, holding a direction until the block reaches the wall is a single press
+const (
+	TAP_LEFT              = 1
+	TAP_RIGHT             = 2
+	DAS_LEFT              = 3
+	DAS_RIGHT             = 4
+	ROTATE_CLOCKWISE      = 5
+	ROTATE_ANTI_CLOCKWISE = 6
+)
+
+// Order in which the presses are tried, it decides which sequence wins when two have the same length
+var INPUTS []int = []int{DAS_LEFT, DAS_RIGHT, TAP_LEFT, TAP_RIGHT, ROTATE_CLOCKWISE, ROTATE_ANTI_CLOCKWISE}
+
+var INPUT_NAMES map[int]string = map[int]string{
+	TAP_LEFT:              "left",
+	TAP_RIGHT:             "right",
+	DAS_LEFT:              "DAS left",
+	DAS_RIGHT:             "DAS right",
+	ROTATE_CLOCKWISE:      "rotate",
+	ROTATE_ANTI_CLOCKWISE: "rotate back",
+}
+
+// rows added above the board, a block may turn out of the top before gravity brought it down
+const HEADROOM = 2
+
+type state struct {
+	block entity.BlockEntity
+	path  []int
+}
+
+// Fewest presses that bring the block from where it spawned above the target, the hard drop not included.
+// Placements a hard drop can't reach, tucks and spins, have no finesse and return false.
+func Minimal(currentBoard board.Board, block entity.BlockEntity, target matrix.Matrix) ([]int, bool) {
+	paddedBoard := pad(currentBoard)
+	block = shift(block)
+	targetKey := movegenerator.PositionKey(shift(entity.BlockEntity{OccupiedPosition: target}).OccupiedPosition)
+
+	if !paddedBoard.Fits(block.OccupiedPosition) {
+		return nil, false
+	}
+
+	visited := map[string]bool{movegenerator.PositionKey(block.OccupiedPosition): true}
+	queue := []state{{block: block, path: []int{}}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if movegenerator.PositionKey(drop(paddedBoard, current.block).OccupiedPosition) == targetKey {
+			return current.path, true
+		}
+
+		for _, input := range INPUTS {
+			nextBlock, ok := apply(paddedBoard, current.block, input)
+			key := movegenerator.PositionKey(nextBlock.OccupiedPosition)
+			if !ok || visited[key] {
+				continue
+			}
+			visited[key] = true
+
+			path := make([]int, len(current.path)+1)
+			copy(path, current.path)
+			path[len(current.path)] = input
+
+			queue = append(queue, state{block: nextBlock, path: path})
+		}
+	}
+
+	return nil, false
+}
+
+// e.g. DAS left, rotate
+func Describe(inputs []int) string {
+	names := make([]string, len(inputs))
+	for i, input := range inputs {
+		names[i] = INPUT_NAMES[input]
+	}
+
+	if len(names) == 0 {
+		return "drop"
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func apply(currentBoard board.Board, block entity.BlockEntity, input int) (entity.BlockEntity, bool) {
+	switch input {
+	case TAP_LEFT:
+		return movegenerator.Apply(currentBoard, block, movegenerator.MOVE_LEFT)
+	case TAP_RIGHT:
+		return movegenerator.Apply(currentBoard, block, movegenerator.MOVE_RIGHT)
+	case DAS_LEFT:
+		return repeat(currentBoard, block, movegenerator.MOVE_LEFT)
+	case DAS_RIGHT:
+		return repeat(currentBoard, block, movegenerator.MOVE_RIGHT)
+	case ROTATE_CLOCKWISE:
+		return movegenerator.Apply(currentBoard, block, movegenerator.ROTATE_CLOCKWISE)
+	case ROTATE_ANTI_CLOCKWISE:
+		return movegenerator.Apply(currentBoard, block, movegenerator.ROTATE_ANTI_CLOCKWISE)
+	}
+
+	return block, false
+}
+
+// Applies the input for as long as the game accepts it
+func repeat(currentBoard board.Board, block entity.BlockEntity, input int) (entity.BlockEntity, bool) {
+	moved := false
+	for {
+		nextBlock, ok := movegenerator.Apply(currentBoard, block, input)
+		if !ok {
+			return block, moved
+		}
+		block, moved = nextBlock, true
+	}
+}
+
+func drop(currentBoard board.Board, block entity.BlockEntity) entity.BlockEntity {
+	dropped, _ := repeat(currentBoard, block, movegenerator.SOFT_DROP)
+	return dropped
+}
+
+// Board with empty rows on top, so that a block may stick out above the original one
+func pad(currentBoard board.Board) board.Board {
+	paddedBoard := board.New(currentBoard.Width, currentBoard.Height+HEADROOM)
+	for y, row := range currentBoard.Cells {
+		copy(paddedBoard.Cells[y+HEADROOM], row)
+	}
+
+	return paddedBoard
+}
+
+func shift(block entity.BlockEntity) entity.BlockEntity {
+	shifted := entity.BlockEntity{EntityType: block.EntityType, Color: block.Color, OccupiedPosition: matrix.Copy(block.OccupiedPosition)}
+	shifted.MoveBlock([2]int{0, HEADROOM})
+
+	return shifted
+}
+
+// A piece that took more presses than it needed
+type Fault struct {
+	Block   entity.BlockEntity // where it locked
+	Presses int
+	Minimal []int
+	Board   board.Board        // before the piece locked
+	Spawned entity.BlockEntity // where it spawned
+}
+
+// Judges the finesse of every piece of a game
+type Trainer struct {
+	Judged  int
+	Faults  int
+	Last    *Fault
+	OnFault func(fault Fault)
+	board   board.Board
+	spawned entity.BlockEntity
+	keys    int // presses of the game before the current piece
+}
+
+// Follows the events of the game, subscribe before the game starts. Presses are counted from one lock to the next,
+// so the ones made before a block spawned count for it. A hold starts the count over.
+func (t *Trainer) Follow(tg *game.TetrisGame) {
+	tg.Events.Subscribe(func(event game.Event) {
+		switch event := event.(type) {
+		case game.PieceSpawned:
+			t.board, t.spawned = tg.Board(), event.Block
+		case game.Hold:
+			t.keys = tg.Keys
+		case game.PieceLocked:
+			presses := tg.Keys - t.keys
+			t.keys = tg.Keys
+			t.judge(event.Block, presses)
+		}
+	})
+}
+
+func (t *Trainer) judge(block entity.BlockEntity, presses int) {
+	minimal, ok := Minimal(t.board, t.spawned, block.OccupiedPosition)
+	if !ok {
+		return
+	}
+
+	t.Judged += 1
+	// one more for the hard drop, letting gravity lock the block takes fewer
+	if presses <= len(minimal)+1 {
+		return
+	}
+
+	t.Faults += 1
+	t.Last = &Fault{Block: block, Presses: presses, Minimal: minimal, Board: t.board, Spawned: t.spawned}
+	if t.OnFault != nil {
+		t.OnFault(*t.Last)
+	}
+}
@@ -0,0 +1,80 @@
+package finesse
+
+import (
+	"slices"
+	"testing"
+	"tetris/board"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	"tetris/matrix"
+	"tetris/spawner"
+)
+
+func TestMinimal(t *testing.T) {
+	currentBoard := board.New(10, 20)
+	block, _ := entity.New(entity.O, entity.YELLOW, [2]int{4, 0})
+
+	if inputs, ok := Minimal(currentBoard, block, matrix.Matrix{{0, 18}, {1, 18}, {0, 19}, {1, 19}}); !ok || !slices.Equal(inputs, []int{DAS_LEFT}) {
+		t.Errorf("O against the left wall should take a single DAS, found %s", Describe(inputs))
+		t.Fail()
+	}
+
+	if inputs, ok := Minimal(currentBoard, block, matrix.Matrix{{2, 18}, {3, 18}, {2, 19}, {3, 19}}); !ok || len(inputs) != 2 {
+		t.Errorf("O two columns to the left of the wall should take two presses, found %s", Describe(inputs))
+		t.Fail()
+	}
+
+	iBlock, _ := entity.New(entity.I, entity.BLUE, [2]int{3, 0})
+	upright := iBlock
+	upright.OccupiedPosition = matrix.Copy(iBlock.OccupiedPosition)
+	upright.RotateBlock(entity.CLOCKWISE)
+	upright = drop(currentBoard, shift(upright))
+
+	// turning the I on the spawn row sticks out of the top, the trainer still knows it turns once it fell
+	if inputs, ok := Minimal(currentBoard, iBlock, upright.OccupiedPosition); !ok || !slices.Equal(inputs, []int{ROTATE_CLOCKWISE}) {
+		t.Errorf("Upright I should take a single rotation, found %s", Describe(inputs))
+		t.Fail()
+	}
+}
+
+func TestTuckHasNoFinesse(t *testing.T) {
+	currentBoard, _ := board.ParseRows([]string{"GGGGGG....", "..........", "GGGGGGGG.."}, 10, 20)
+	block, _ := entity.New(entity.O, entity.YELLOW, [2]int{4, 0})
+
+	if _, ok := Minimal(currentBoard, block, matrix.Matrix{{0, 17}, {1, 17}, {0, 18}, {1, 18}}); ok {
+		t.Error("O tucked under the overhang can't be hard dropped there")
+		t.Fail()
+	}
+}
+
+func TestTrainerCountsFaults(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	tetrisGame.Spawner.Sequence = spawner.NewSequence([]int{entity.O, entity.O})
+	trainer := Trainer{}
+	trainer.Follow(&tetrisGame)
+	faults := 0
+	trainer.OnFault = func(fault Fault) { faults += 1 }
+	tetrisGame.Start()
+
+	// turning the O does nothing, the two presses are wasted
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{RotateDirection: entity.CLOCKWISE, Presses: 1})
+	tetrisGame.Update(eventhandler.UpdateEvent{RotateDirection: entity.ANTI_CLOCKWISE, Presses: 1})
+	tetrisGame.Update(eventhandler.UpdateEvent{HardDrop: true, Presses: 1})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{HardDrop: true, Presses: 1})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	if trainer.Judged != 2 || trainer.Faults != 1 || faults != 1 {
+		t.Errorf("Expected a fault in 2 pieces, found %d in %d", trainer.Faults, trainer.Judged)
+		t.FailNow()
+	}
+
+	if trainer.Last.Presses != 3 || len(trainer.Last.Minimal) != 0 {
+		t.Errorf("Dropping the O right away would have done, found %d presses instead of %s", trainer.Last.Presses, Describe(trainer.Last.Minimal))
+		t.Fail()
+	}
+}
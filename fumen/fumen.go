@@ -0,0 +1,636 @@
+package fumen
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"tetris/board"
+	"tetris/entity"
+	"tetris/matrix"
+	"unicode/utf16"
+)
+
+// Version 115 of the fumen format, the one fumen.zui.jp and most community tools write.
+// Fields are always 10 cells wide with 23 rows and a garbage row below the floor that can rise into the field.
+const (
+	VERSION       = "115"
+	FIELD_WIDTH   = 10
+	FIELD_HEIGHT  = 23
+	FIELD_ROWS    = FIELD_HEIGHT + 1 // with the garbage row
+	FIELD_BLOCKS  = FIELD_ROWS * FIELD_WIDTH
+	MAX_COMMENT   = 4095
+	LINE_BREAK    = 47 // a '?' is put after every chunk of this many characters
+	FIRST_BREAK   = 42
+	ENCODE_TABLE  = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	COMMENT_TABLE = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+)
+
+// Fumen numbers of the pieces, 0 is an empty cell and 8 garbage
+var PIECE_NUMBERS map[int]int = map[int]int{
+	entity.I:      1,
+	entity.L:      2,
+	entity.O:      3,
+	entity.Z:      4,
+	entity.T:      5,
+	entity.J:      6,
+	entity.S:      7,
+	board.GARBAGE: 8,
+}
+
+// Fumen rotations, spawn is 2 and the others follow clockwise from it backwards
+const (
+	REVERSE = 0
+	RIGHT   = 1
+	SPAWN   = 2
+	LEFT    = 3
+)
+
+// Cells of every piece around its rotation center in spawn orientation, y grows upwards like in fumen
+var PIECE_SHAPES map[int][][2]int = map[int][][2]int{
+	1: {{0, 0}, {-1, 0}, {1, 0}, {2, 0}},
+	2: {{0, 0}, {-1, 0}, {1, 0}, {1, 1}},
+	3: {{0, 0}, {1, 0}, {0, 1}, {1, 1}},
+	4: {{0, 0}, {1, 0}, {0, 1}, {-1, 1}},
+	5: {{0, 0}, {-1, 0}, {1, 0}, {0, 1}},
+	6: {{0, 0}, {-1, 0}, {1, 0}, {-1, 1}},
+	7: {{0, 0}, {-1, 0}, {0, 1}, {1, 1}},
+}
+
+// Fumen keeps an older rotation center for some pieces, this is how far it is from the one of PIECE_SHAPES
+var CENTER_OFFSETS map[[2]int][2]int = map[[2]int][2]int{
+	{3, LEFT}:    {1, -1},
+	{3, REVERSE}: {1, 0},
+	{3, SPAWN}:   {0, -1},
+	{1, REVERSE}: {1, 0},
+	{1, LEFT}:    {0, -1},
+	{7, SPAWN}:   {0, -1},
+	{7, RIGHT}:   {-1, 0},
+	{4, SPAWN}:   {0, -1},
+	{4, LEFT}:    {1, 0},
+}
+
+// A single page of a fumen. The board is the field before the piece of the page locks, in the coordinates
+// of the game. Decoded boards are FIELD_WIDTH by FIELD_HEIGHT, lower boards sit on the floor of the field
+// when they get encoded. Without a piece the page only shows the board.
+type Page struct {
+	Board   board.Board
+	Piece   *entity.BlockEntity
+	Comment string
+	Lock    bool // the piece locks into the field of the next page and full rows are cleared
+	Rise    bool // the garbage row rises into the field after the piece locked
+	Mirror  bool // the field is mirrored after the piece locked
+}
+
+// Cells of the field by fumen number, the first row is the top one and the last one is the garbage row
+type field [FIELD_ROWS][FIELD_WIDTH]int
+
+type action struct {
+	piece    int
+	rotation int
+	position int
+	rise     bool
+	mirror   bool
+	colorize bool
+	comment  bool
+	lock     bool
+}
+
+func NewPage(b board.Board, piece *entity.BlockEntity) Page {
+	return Page{Board: b, Piece: piece, Lock: true}
+}
+
+// Pages of a fumen, with or without the address of the page in front of it
+func Decode(data string) ([]Page, error) {
+	start := -1
+	for _, prefix := range []string{"v", "m", "d"} {
+		if index := strings.Index(data, prefix+VERSION+"@"); index >= 0 && (start < 0 || index < start) {
+			start = index
+		}
+	}
+
+	if start < 0 {
+		return nil, errors.New(fmt.Sprintf("Only version %s of fumen is supported", VERSION))
+	}
+
+	values := &reader{data: strings.ReplaceAll(strings.TrimSpace(data[start+len(VERSION)+2:]), "?", "")}
+	pages := make([]Page, 0)
+	previousField := field{}
+	repeat := 0
+	comment := ""
+
+	for !values.empty() {
+		currentField := previousField
+		if repeat > 0 {
+			repeat -= 1
+		} else {
+			changed, err := values.field(&currentField)
+			if err != nil {
+				return nil, err
+			}
+			if !changed {
+				repeat, err = values.poll(1)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		value, err := values.poll(3)
+		if err != nil {
+			return nil, err
+		}
+		currentAction := decodeAction(value)
+
+		if currentAction.comment {
+			comment, err = values.comment()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		page := Page{
+			Board:   currentField.board(),
+			Comment: comment,
+			Lock:    currentAction.lock,
+			Rise:    currentAction.rise,
+			Mirror:  currentAction.mirror,
+		}
+
+		cells, hasPiece := currentAction.cells()
+		if hasPiece {
+			block, err := board.BlockAt(pieceType(currentAction.piece), gameCells(cells))
+			if err != nil {
+				return nil, err
+			}
+			page.Piece = &block
+		}
+		pages = append(pages, page)
+
+		previousField = currentField
+		if currentAction.lock {
+			previousField = currentField.next(currentAction.piece, cells, currentAction.rise, currentAction.mirror)
+		}
+	}
+
+	if len(pages) == 0 {
+		return nil, errors.New("Fumen has no pages")
+	}
+
+	return pages, nil
+}
+
+func Encode(pages []Page) (string, error) {
+	values := &writer{}
+	previousField := field{}
+	repeatIndex := -1
+	comment := ""
+
+	for i, page := range pages {
+		currentField, err := fieldOf(page.Board)
+		if err != nil {
+			return "", err
+		}
+
+		fieldValues := &writer{}
+		if fieldValues.field(previousField, currentField) {
+			values.values = append(values.values, fieldValues.values...)
+			repeatIndex = -1
+		} else if repeatIndex < 0 || values.values[repeatIndex] == len(ENCODE_TABLE)-1 {
+			values.values = append(values.values, fieldValues.values...)
+			values.push(0, 1)
+			repeatIndex = len(values.values) - 1
+		} else {
+			// an unchanged field right after another one is left out, only the count of repeats goes up
+			values.values[repeatIndex] += 1
+		}
+
+		currentAction := action{
+			rotation: REVERSE,
+			colorize: i == 0,
+			comment:  page.Comment != comment,
+			lock:     page.Lock,
+			rise:     page.Rise,
+			mirror:   page.Mirror,
+		}
+
+		if page.Piece != nil {
+			currentAction.piece, currentAction.rotation, currentAction.position, err = placementOf(*page.Piece, FIELD_HEIGHT-page.Board.Height)
+			if err != nil {
+				return "", err
+			}
+		}
+		values.push(currentAction.encode(), 3)
+
+		if currentAction.comment {
+			values.comment(page.Comment)
+			comment = page.Comment
+		}
+
+		previousField = currentField
+		if page.Lock {
+			cells, _ := currentAction.cells()
+			previousField = currentField.next(currentAction.piece, cells, page.Rise, page.Mirror)
+		}
+	}
+
+	data := values.String()
+	chunks := make([]string, 0)
+	for size := FIRST_BREAK; len(data) > size; size = LINE_BREAK {
+		chunks = append(chunks, data[:size])
+		data = data[size:]
+	}
+
+	return "v" + VERSION + "@" + strings.Join(append(chunks, data), "?"), nil
+}
+
+func decodeAction(value int) action {
+	decoded := action{}
+	decoded.piece = value % 8
+	value /= 8
+	decoded.rotation = value % 4
+	value /= 4
+	decoded.position = value % FIELD_BLOCKS
+	value /= FIELD_BLOCKS
+	decoded.rise = value%2 == 1
+	value /= 2
+	decoded.mirror = value%2 == 1
+	value /= 2
+	decoded.colorize = value%2 == 1
+	value /= 2
+	decoded.comment = value%2 == 1
+	value /= 2
+	decoded.lock = value%2 == 0
+
+	return decoded
+}
+
+func (a action) encode() int {
+	value := 0
+	for _, flag := range []bool{!a.lock, a.comment, a.colorize, a.mirror, a.rise} {
+		value *= 2
+		if flag {
+			value += 1
+		}
+	}
+
+	value = value*FIELD_BLOCKS + a.position
+	value = value*4 + a.rotation
+	return value*8 + a.piece
+}
+
+// Cells of the piece in the fumen field, x to the right and y up from the floor
+func (a action) cells() ([][2]int, bool) {
+	shape, ok := PIECE_SHAPES[a.piece]
+	if !ok {
+		return nil, false
+	}
+
+	offset := CENTER_OFFSETS[[2]int{a.piece, a.rotation}]
+	x := a.position%FIELD_WIDTH + offset[0]
+	y := FIELD_HEIGHT - a.position/FIELD_WIDTH - 1 + offset[1]
+
+	cells := make([][2]int, len(shape))
+	for i, cell := range rotate(shape, a.rotation) {
+		cells[i] = [2]int{x + cell[0], y + cell[1]}
+	}
+
+	return cells, true
+}
+
+func rotate(shape [][2]int, rotation int) [][2]int {
+	rotated := make([][2]int, len(shape))
+
+	for i, cell := range shape {
+		switch rotation {
+		case RIGHT:
+			rotated[i] = [2]int{cell[1], -cell[0]}
+		case REVERSE:
+			rotated[i] = [2]int{-cell[0], -cell[1]}
+		case LEFT:
+			rotated[i] = [2]int{-cell[1], cell[0]}
+		default:
+			rotated[i] = cell
+		}
+	}
+
+	return rotated
+}
+
+// Fumen piece, rotation and position of a block, top is how far the board of the block is below the top of the field
+func placementOf(block entity.BlockEntity, top int) (int, int, int, error) {
+	number, ok := PIECE_NUMBERS[block.EntityType]
+	if !ok || number == PIECE_NUMBERS[board.GARBAGE] {
+		return 0, 0, 0, errors.New(fmt.Sprintf("Block type %d has no fumen piece", block.EntityType))
+	}
+
+	target := make(map[[2]int]bool)
+	for _, location := range block.OccupiedPosition {
+		target[[2]int{location[0], FIELD_HEIGHT - 1 - top - location[1]}] = true
+	}
+
+	for _, rotation := range []int{SPAWN, RIGHT, REVERSE, LEFT} {
+		shape := rotate(PIECE_SHAPES[number], rotation)
+		for center := range target {
+			matches := true
+			for _, cell := range shape {
+				matches = matches && target[[2]int{center[0] + cell[0], center[1] + cell[1]}]
+			}
+
+			offset := CENTER_OFFSETS[[2]int{number, rotation}]
+			x, y := center[0]-offset[0], center[1]-offset[1]
+			if matches && x >= 0 && x < FIELD_WIDTH && y >= 0 && y < FIELD_HEIGHT {
+				return number, rotation, (FIELD_HEIGHT-y-1)*FIELD_WIDTH + x, nil
+			}
+		}
+	}
+
+	return 0, 0, 0, errors.New(fmt.Sprintf("Block at %v is outside of the fumen field", block.OccupiedPosition))
+}
+
+// Game coordinates of cells in the fumen field
+func gameCells(cells [][2]int) matrix.Matrix {
+	positions := make(matrix.Matrix, len(cells))
+	for i, cell := range cells {
+		positions[i] = []int{cell[0], FIELD_HEIGHT - 1 - cell[1]}
+	}
+
+	return positions
+}
+
+func pieceType(number int) int {
+	for piece, pieceNumber := range PIECE_NUMBERS {
+		if pieceNumber == number {
+			return piece
+		}
+	}
+
+	return board.EMPTY
+}
+
+func fieldOf(b board.Board) (field, error) {
+	current := field{}
+	if b.Width != FIELD_WIDTH || b.Height > FIELD_HEIGHT {
+		return current, errors.New(fmt.Sprintf("Fumen fields are %d by %d, the board is %d by %d", FIELD_WIDTH, FIELD_HEIGHT, b.Width, b.Height))
+	}
+
+	// smaller boards sit on the floor of the field
+	top := FIELD_HEIGHT - b.Height
+	for y := range b.Height {
+		for x := range b.Width {
+			if b.Occupied(x, y) {
+				current[top+y][x] = PIECE_NUMBERS[board.GARBAGE]
+				if number, ok := PIECE_NUMBERS[b.Piece(x, y)]; ok {
+					current[top+y][x] = number
+				}
+			}
+		}
+	}
+
+	return current, nil
+}
+
+func (f field) board() board.Board {
+	b := board.New(FIELD_WIDTH, FIELD_HEIGHT)
+
+	for y := range FIELD_HEIGHT {
+		for x := range FIELD_WIDTH {
+			if f[y][x] != 0 {
+				piece := pieceType(f[y][x])
+				b.Set(x, y, board.PieceColor(piece), piece)
+			}
+		}
+	}
+
+	return b
+}
+
+// Field of the next page once the piece locked
+func (f field) next(piece int, cells [][2]int, rise, mirror bool) field {
+	for _, cell := range cells {
+		x, y := cell[0], FIELD_HEIGHT-1-cell[1]
+		if x >= 0 && x < FIELD_WIDTH && y >= 0 && y < FIELD_HEIGHT {
+			f[y][x] = piece
+		}
+	}
+
+	cleared := field{}
+	row := FIELD_HEIGHT - 1
+	for y := FIELD_HEIGHT - 1; y >= 0; y-- {
+		full := true
+		for x := range FIELD_WIDTH {
+			full = full && f[y][x] != 0
+		}
+		if !full {
+			cleared[row] = f[y]
+			row -= 1
+		}
+	}
+	cleared[FIELD_HEIGHT] = f[FIELD_HEIGHT]
+
+	if rise {
+		copy(cleared[:FIELD_HEIGHT-1], cleared[1:FIELD_HEIGHT])
+		cleared[FIELD_HEIGHT-1] = cleared[FIELD_HEIGHT]
+		cleared[FIELD_HEIGHT] = [FIELD_WIDTH]int{}
+	}
+
+	if mirror {
+		for y := range FIELD_HEIGHT {
+			for x := range FIELD_WIDTH / 2 {
+				cleared[y][x], cleared[y][FIELD_WIDTH-1-x] = cleared[y][FIELD_WIDTH-1-x], cleared[y][x]
+			}
+		}
+	}
+
+	return cleared
+}
+
+type reader struct {
+	data     string
+	position int
+}
+
+func (r *reader) empty() bool {
+	return r.position >= len(r.data)
+}
+
+// Reads a number written with count characters, the lowest digit first
+func (r *reader) poll(count int) (int, error) {
+	value, base := 0, 1
+
+	for range count {
+		if r.empty() {
+			return 0, errors.New("Fumen ended too early")
+		}
+
+		digit := strings.IndexByte(ENCODE_TABLE, r.data[r.position])
+		if digit < 0 {
+			return 0, errors.New(fmt.Sprintf("Unexpected character %q in fumen", r.data[r.position]))
+		}
+
+		value += digit * base
+		base *= len(ENCODE_TABLE)
+		r.position += 1
+	}
+
+	return value, nil
+}
+
+// Applies the difference to the previous field, false when the field didn't change at all
+func (r *reader) field(current *field) (bool, error) {
+	changed := true
+
+	for index := 0; index < FIELD_BLOCKS; {
+		value, err := r.poll(2)
+		if err != nil {
+			return false, err
+		}
+
+		difference, count := value/FIELD_BLOCKS-8, value%FIELD_BLOCKS+1
+		if difference == 0 && count == FIELD_BLOCKS {
+			changed = false
+		}
+
+		for range count {
+			if index >= FIELD_BLOCKS {
+				return false, errors.New("Fumen field has too many cells")
+			}
+
+			y, x := index/FIELD_WIDTH, index%FIELD_WIDTH
+			current[y][x] += difference
+			if current[y][x] < 0 || current[y][x] > 8 {
+				return false, errors.New("Fumen field has an unknown cell")
+			}
+			index += 1
+		}
+	}
+
+	return changed, nil
+}
+
+func (r *reader) comment() (string, error) {
+	length, err := r.poll(2)
+	if err != nil {
+		return "", err
+	}
+
+	escaped := make([]byte, 0, length+3)
+	for range (length + 3) / 4 {
+		value, err := r.poll(5)
+		if err != nil {
+			return "", err
+		}
+
+		for range 4 {
+			escaped = append(escaped, COMMENT_TABLE[value%(len(COMMENT_TABLE)+1)])
+			value /= len(COMMENT_TABLE) + 1
+		}
+	}
+
+	return unescape(string(escaped[:length])), nil
+}
+
+type writer struct {
+	values []int
+}
+
+// Writes the number with count characters, the lowest digit first
+func (w *writer) push(value, count int) {
+	for range count {
+		w.values = append(w.values, value%len(ENCODE_TABLE))
+		value /= len(ENCODE_TABLE)
+	}
+}
+
+// Difference of every cell as runs of the same difference, returns whether anything changed
+func (w *writer) field(previous, current field) bool {
+	difference := func(index int) int {
+		y, x := index/FIELD_WIDTH, index%FIELD_WIDTH
+		return current[y][x] - previous[y][x] + 8
+	}
+
+	changed := false
+	runDifference, runLength := difference(0), 0
+	for index := 1; index < FIELD_BLOCKS; index++ {
+		if difference(index) == runDifference {
+			runLength += 1
+			continue
+		}
+
+		w.push(runDifference*FIELD_BLOCKS+runLength, 2)
+		runDifference, runLength = difference(index), 0
+		changed = true
+	}
+	w.push(runDifference*FIELD_BLOCKS+runLength, 2)
+
+	return changed || runDifference != 8
+}
+
+func (w *writer) comment(comment string) {
+	escaped := escape(comment)
+	if len(escaped) > MAX_COMMENT {
+		escaped = escaped[:MAX_COMMENT]
+	}
+	w.push(len(escaped), 2)
+
+	for index := 0; index < len(escaped); index += 4 {
+		value, base := 0, 1
+		for _, character := range []byte(escaped[index:min(index+4, len(escaped))]) {
+			value += strings.IndexByte(COMMENT_TABLE, character) * base
+			base *= len(COMMENT_TABLE) + 1
+		}
+		w.push(value, 5)
+	}
+}
+
+func (w writer) String() string {
+	data := make([]byte, len(w.values))
+	for i, value := range w.values {
+		data[i] = ENCODE_TABLE[value]
+	}
+
+	return string(data)
+}
+
+// Same as escape of JavaScript, fumen stores its comments that way
+func escape(text string) string {
+	escaped := strings.Builder{}
+
+	for _, unit := range utf16.Encode([]rune(text)) {
+		if unit < 128 && strings.ContainsRune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789@*_+-./", rune(unit)) {
+			escaped.WriteByte(byte(unit))
+		} else if unit < 256 {
+			escaped.WriteString(fmt.Sprintf("%%%02X", unit))
+		} else {
+			escaped.WriteString(fmt.Sprintf("%%u%04X", unit))
+		}
+	}
+
+	return escaped.String()
+}
+
+func unescape(text string) string {
+	units := make([]uint16, 0, len(text))
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == '%' && i+5 < len(text) && text[i+1] == 'u' {
+			if value, err := strconv.ParseUint(text[i+2:i+6], 16, 16); err == nil {
+				units = append(units, uint16(value))
+				i += 5
+				continue
+			}
+		}
+
+		if text[i] == '%' && i+2 < len(text) {
+			if value, err := strconv.ParseUint(text[i+1:i+3], 16, 8); err == nil {
+				units = append(units, uint16(value))
+				i += 2
+				continue
+			}
+		}
+
+		units = append(units, uint16(text[i]))
+	}
+
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,313 @@
+package fumen
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"tetris/board"
+	"tetris/bot"
+	"tetris/entity"
+	"tetris/game"
+	"tetris/matrix"
+)
+
+func parseField(t *testing.T, rows ...string) board.Board {
+	b, err := board.ParseRows(rows, FIELD_WIDTH, FIELD_HEIGHT)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	return b
+}
+
+func sameCells(m1, m2 matrix.Matrix) bool {
+	cells := func(m matrix.Matrix) []string {
+		result := make([]string, len(m))
+		for i, location := range m {
+			result[i] = matrix.Matrix{location}.ToString()
+		}
+		slices.Sort(result)
+		return result
+	}
+
+	return slices.Equal(cells(m1), cells(m2))
+}
+
+func TestEmptyPage(t *testing.T) {
+	data, err := Encode([]Page{NewPage(board.New(FIELD_WIDTH, FIELD_HEIGHT), nil)})
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if data != "v115@vhAAgH" {
+		t.Errorf("Empty page should encode to v115@vhAAgH, found %s", data)
+		t.Fail()
+	}
+
+	pages, err := Decode("https://fumen.zui.jp/?" + data)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if len(pages) != 1 || pages[0].Piece != nil || len(pages[0].Board.StackRows()) != 0 {
+		t.Errorf("Expected a single empty page, found %v", pages)
+		t.Fail()
+	}
+}
+
+func TestPiecesInEveryRotation(t *testing.T) {
+	for piece := range PIECE_NUMBERS {
+		if piece == board.GARBAGE {
+			continue
+		}
+
+		// the O of the game does not turn in place, only the way it spawns matters
+		rotations := 4
+		if piece == entity.O {
+			rotations = 1
+		}
+
+		block, _ := entity.New(piece, board.PieceColor(piece), [2]int{4, 10})
+		for range rotations {
+			number, rotation, position, err := placementOf(block, 0)
+			if err != nil {
+				t.Error(err.Error())
+				t.FailNow()
+			}
+
+			cells, _ := action{piece: number, rotation: rotation, position: position}.cells()
+			if !sameCells(gameCells(cells), block.OccupiedPosition) {
+				t.Errorf("Piece %c should stay at %v, found %v", board.PIECE_LETTERS[piece], block.OccupiedPosition, gameCells(cells))
+				t.Fail()
+			}
+
+			block.RotateBlock(entity.CLOCKWISE)
+		}
+	}
+}
+
+// Every piece in every rotation as fumen.zui.jp writes it, with its center two rows above the floor.
+// Some pieces cover the same cells in two rotations, only the rotation placementOf finds first gets written.
+var GOLDEN_PIECES = []struct {
+	data    string
+	written bool
+	rows    []string
+}{
+	{"v115@vhARGJ", true, []string{"..........", "..........", "...iiii...", "..........", ".........."}},
+	{"v115@vhAJGJ", true, []string{"..........", "....i.....", "....i.....", "....i.....", "....i....."}},
+	{"v115@vhAhFJ", false, []string{"..........", "..........", "..iiii....", "..........", ".........."}},
+	{"v115@vhAZBJ", false, []string{"....i.....", "....i.....", "....i.....", "....i.....", ".........."}},
+	{"v115@vhASGJ", true, []string{"..........", ".....l....", "...lll....", "..........", ".........."}},
+	{"v115@vhAKGJ", true, []string{"..........", "....l.....", "....l.....", "....ll....", ".........."}},
+	{"v115@vhACGJ", true, []string{"..........", "..........", "...lll....", "...l......", ".........."}},
+	{"v115@vhAaGJ", true, []string{"..........", "...ll.....", "....l.....", "....l.....", ".........."}},
+	{"v115@vhATBJ", true, []string{"..........", "....oo....", "....oo....", "..........", ".........."}},
+	{"v115@vhALGJ", false, []string{"..........", "..........", "....oo....", "....oo....", ".........."}},
+	{"v115@vhAjFJ", false, []string{"..........", "..........", "...oo.....", "...oo.....", ".........."}},
+	{"v115@vhA7AJ", false, []string{"..........", "...oo.....", "...oo.....", "..........", ".........."}},
+	{"v115@vhAUBJ", true, []string{"..........", "...zz.....", "....zz....", "..........", ".........."}},
+	{"v115@vhAMGJ", true, []string{"..........", ".....z....", "....zz....", "....z.....", ".........."}},
+	{"v115@vhAEGJ", false, []string{"..........", "..........", "...zz.....", "....zz....", ".........."}},
+	{"v115@vhA8FJ", false, []string{"..........", "....z.....", "...zz.....", "...z......", ".........."}},
+	{"v115@vhAVGJ", true, []string{"..........", "....t.....", "...ttt....", "..........", ".........."}},
+	{"v115@vhANGJ", true, []string{"..........", "....t.....", "....tt....", "....t.....", ".........."}},
+	{"v115@vhAFGJ", true, []string{"..........", "..........", "...ttt....", "....t.....", ".........."}},
+	{"v115@vhAdGJ", true, []string{"..........", "....t.....", "...tt.....", "....t.....", ".........."}},
+	{"v115@vhAWGJ", true, []string{"..........", "...j......", "...jjj....", "..........", ".........."}},
+	{"v115@vhAOGJ", true, []string{"..........", "....jj....", "....j.....", "....j.....", ".........."}},
+	{"v115@vhAGGJ", true, []string{"..........", "..........", "...jjj....", ".....j....", ".........."}},
+	{"v115@vhAeGJ", true, []string{"..........", "....j.....", "....j.....", "...jj.....", ".........."}},
+	{"v115@vhAXBJ", true, []string{"..........", "....ss....", "...ss.....", "..........", ".........."}},
+	{"v115@vhAvGJ", true, []string{"..........", "....s.....", "....ss....", ".....s....", ".........."}},
+	{"v115@vhAHGJ", false, []string{"..........", "..........", "....ss....", "...ss.....", ".........."}},
+	{"v115@vhAfGJ", false, []string{"..........", "...s......", "...ss.....", "....s.....", ".........."}},
+}
+
+func TestGoldenPieces(t *testing.T) {
+	for _, golden := range GOLDEN_PIECES {
+		pages, err := Decode(golden.data)
+		if err != nil || len(pages) != 1 || pages[0].Piece == nil {
+			t.Errorf("%s should decode to a single page with a piece", golden.data)
+			t.FailNow()
+		}
+
+		rows := strings.Split(pages[0].Board.PrintPlacement(*pages[0].Piece), "\n")
+		if decoded := rows[len(rows)-len(golden.rows):]; !slices.Equal(decoded, golden.rows) {
+			t.Errorf("%s should decode to\n%s\nfound\n%s", golden.data, strings.Join(golden.rows, "\n"), strings.Join(decoded, "\n"))
+			t.Fail()
+		}
+
+		if !golden.written {
+			continue
+		}
+
+		b, block, err := board.ParsePlacement(strings.Join(golden.rows, "\n"))
+		if err != nil {
+			t.Error(err.Error())
+			t.FailNow()
+		}
+
+		if data, err := Encode([]Page{NewPage(b, &block)}); err != nil || data != golden.data {
+			t.Errorf("Expected the placement\n%s\nto encode to %s, found %s", strings.Join(golden.rows, "\n"), golden.data, data)
+			t.Fail()
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	b := parseField(t,
+		"GGGG......",
+		"GGG...GGGG",
+		"GGGG.GGGGG",
+		"GGGGGGGGG.",
+	)
+	tBlock, _ := board.BlockAt(entity.T, matrix.Matrix{{4, 21}, {4, 20}, {5, 20}, {3, 20}})
+	iBlock, _ := board.BlockAt(entity.I, matrix.Matrix{{9, 22}, {9, 21}, {9, 20}, {9, 19}})
+	sBlock, _ := board.BlockAt(entity.S, matrix.Matrix{{4, 22}, {5, 22}, {5, 21}, {6, 21}})
+
+	pages := []Page{
+		{Board: b, Piece: &tBlock, Comment: "T-spin double", Lock: true},
+		{Board: b, Piece: &iBlock, Comment: "T-spin double"},
+		{Board: b, Piece: &iBlock, Comment: "テトリス", Lock: true, Mirror: true},
+		{Board: parseField(t, "GGG.GGGGGG"), Piece: &sBlock, Lock: true, Rise: true},
+		{Board: parseField(t, "GGG.GGGGGG"), Comment: "テトリス"},
+	}
+
+	data, err := Encode(pages)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	decoded, err := Decode(data)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if len(decoded) != len(pages) {
+		t.Errorf("Expected %d pages, found %d in %s", len(pages), len(decoded), data)
+		t.FailNow()
+	}
+
+	for i, page := range pages {
+		found := decoded[i]
+		if found.Board.String() != page.Board.String() {
+			t.Errorf("Page %d should have the board\n%s\nfound\n%s", i, page.Board.StackRows(), found.Board.StackRows())
+			t.Fail()
+		}
+
+		if (found.Piece == nil) != (page.Piece == nil) || found.Piece != nil && (found.Piece.EntityType != page.Piece.EntityType || !sameCells(found.Piece.OccupiedPosition, page.Piece.OccupiedPosition)) {
+			t.Errorf("Page %d should have the piece %v, found %v", i, page.Piece, found.Piece)
+			t.Fail()
+		}
+
+		if found.Comment != page.Comment || found.Lock != page.Lock || found.Rise != page.Rise || found.Mirror != page.Mirror {
+			t.Errorf("Page %d should be %v, found %v", i, page, found)
+			t.Fail()
+		}
+	}
+}
+
+func TestLockClearsLines(t *testing.T) {
+	current, _ := fieldOf(parseField(t,
+		"GGG.......",
+		"GGG...GGGG",
+		"GGGG.GGGGG",
+		"GGGGGGGGG.",
+	))
+	tBlock, _ := board.BlockAt(entity.T, matrix.Matrix{{4, 21}, {4, 20}, {5, 20}, {3, 20}})
+	number, rotation, position, _ := placementOf(tBlock, 0)
+	cells, _ := action{piece: number, rotation: rotation, position: position}.cells()
+
+	expected := "GGG.......\nGGGGGGGGG."
+	if rows := strings.Join(current.next(number, cells, false, false).board().StackRows(), "\n"); rows != expected {
+		t.Errorf("Locking the T should clear 2 lines, expected\n%s\nfound\n%s", expected, rows)
+		t.Fail()
+	}
+
+	// the empty garbage row rises in below the stack
+	expected = ".......GGG\nGGGG...GGG\nGGGGG.GGGG\n.GGGGGGGGG\n.........."
+	if rows := strings.Join(current.next(0, nil, true, true).board().StackRows(), "\n"); rows != expected {
+		t.Errorf("Garbage rise and mirror should move the board, found\n%s", rows)
+		t.Fail()
+	}
+}
+
+func TestLongFumenIsSplit(t *testing.T) {
+	pages := make([]Page, 0)
+	for i := range 20 {
+		pages = append(pages, Page{Board: board.New(FIELD_WIDTH, FIELD_HEIGHT), Comment: strings.Repeat("a", i)})
+	}
+
+	data, _ := Encode(pages)
+	parts := strings.Split(strings.TrimPrefix(data, "v115@"), "?")
+	if len(parts) < 2 || len(parts[0]) != FIRST_BREAK || len(parts[1]) != LINE_BREAK {
+		t.Errorf("Long fumens should be split every %d characters, found %s", LINE_BREAK, data)
+		t.Fail()
+	}
+
+	if decoded, err := Decode(data); err != nil || len(decoded) != len(pages) || decoded[19].Comment != pages[19].Comment {
+		t.Errorf("Split fumen should decode the same way, found %v", err)
+		t.Fail()
+	}
+}
+
+func TestRecordGame(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	tetrisGame.Start()
+
+	recorder := Recorder{}
+	heuristicBot := bot.New(bot.DEFAULT_WEIGHTS)
+	for tetrisGame.State == game.PLAY && tetrisGame.Pieces < 30 {
+		tetrisGame.Update(heuristicBot.NextEvent(&tetrisGame))
+		recorder.Observe(&tetrisGame)
+	}
+
+	if len(recorder.Pages) != tetrisGame.Pieces {
+		t.Errorf("Expected a page for each of the %d pieces, found %d", tetrisGame.Pieces, len(recorder.Pages))
+		t.FailNow()
+	}
+
+	data, err := recorder.Encode()
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	pages, err := Decode(data)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	// every page of the replay follows from locking the piece of the page before
+	for i := 1; i < len(pages); i++ {
+		previous, _ := fieldOf(pages[i-1].Board)
+		number, rotation, position, _ := placementOf(*pages[i-1].Piece, 0)
+		cells, _ := action{piece: number, rotation: rotation, position: position}.cells()
+		if previous.next(number, cells, false, false).board().String() != pages[i].Board.String() {
+			t.Errorf("Page %d does not follow from page %d", i, i-1)
+			t.FailNow()
+		}
+	}
+}
+
+func TestPracticePuzzle(t *testing.T) {
+	tBlock, _ := board.BlockAt(entity.T, matrix.Matrix{{4, 21}, {4, 20}, {5, 20}, {3, 20}})
+	b := parseField(t, "GGG...GGGG", "GGGG.GGGGG")
+
+	p, err := Puzzle([]Page{NewPage(b, &tBlock), {Board: b}}, "Imported")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if !slices.Equal(p.Board, []string{"GGG...GGGG", "GGGG.GGGGG"}) || !slices.Equal(p.Sequence, []string{"T"}) {
+		t.Errorf("Expected the board and the T of the fumen, found %v", p)
+		t.Fail()
+	}
+}
@@ -0,0 +1,73 @@
+package fumen
+
+import (
+	"errors"
+	"tetris/board"
+	"tetris/entity"
+	"tetris/game"
+	"tetris/matrix"
+	"tetris/puzzle"
+)
+
+// Page of the game as it is right now
+func FromGame(tg *game.TetrisGame) Page {
+	return NewPage(tg.Board(), copyBlock(tg.CurrentBlock))
+}
+
+func copyBlock(block *entity.BlockEntity) *entity.BlockEntity {
+	if block == nil {
+		return nil
+	}
+
+	copied := *block
+	copied.OccupiedPosition = matrix.Copy(block.OccupiedPosition)
+	return &copied
+}
+
+// Collects a page for every block that locks, so that a whole game can be shared as a fumen.
+// Hook Observe into the OnUpdate of the game.
+type Recorder struct {
+	Pages  []Page
+	pieces int
+	board  board.Board
+	block  *entity.BlockEntity
+}
+
+func (r *Recorder) Observe(tg *game.TetrisGame) {
+	// the board and the block of the update before are the ones right before the block locked
+	if tg.Pieces > r.pieces && r.block != nil {
+		r.Pages = append(r.Pages, NewPage(r.board, r.block))
+	}
+	r.pieces = tg.Pieces
+
+	r.board = tg.Board()
+	r.block = copyBlock(tg.CurrentBlock)
+}
+
+func (r Recorder) Encode() (string, error) {
+	if len(r.Pages) == 0 {
+		return "", errors.New("No block locked yet")
+	}
+
+	return Encode(r.Pages)
+}
+
+// Practice board from the first page of a fumen, the pieces of the pages become the opening sequence
+func Puzzle(pages []Page, name string) (puzzle.Puzzle, error) {
+	p := puzzle.Puzzle{
+		Name:     name,
+		Rules:    puzzle.DEFAULT_RULES,
+		Board:    pages[0].Board.StackRows(),
+		Sequence: make([]string, 0),
+		Hold:     true,
+		Goal:     puzzle.Goal{Type: puzzle.GOAL_PRACTICE, Count: 1},
+	}
+
+	for _, page := range pages {
+		if page.Piece != nil {
+			p.Sequence = append(p.Sequence, string(board.PIECE_LETTERS[page.Piece.EntityType]))
+		}
+	}
+
+	return p, p.Validate()
+}
@@ -0,0 +1,111 @@
+package game
+
+import (
+	"strings"
+	"tetris/entity"
+)
+
+// Something that happened in a game, see the types below
+type Event interface {
+	event()
+}
+
+type PieceSpawned struct {
+	Block entity.BlockEntity
+}
+
+// The player moved the block left or right, gravity and soft drop don't count
+type PieceMoved struct {
+	Block entity.BlockEntity
+}
+
+type PieceRotated struct {
+	Block entity.BlockEntity
+}
+
+type PieceLocked struct {
+	Block    entity.BlockEntity
+	HardDrop bool
+}
+
+// Sent right after the lock that cleared the lines, a t-spin without lines is sent as well
+type LinesCleared struct {
+	Count        int
+	TSpin        bool
+	PerfectClear bool
+	Garbage      int // how many of the lines were garbage
+}
+
+type LevelUp struct {
+	Level int
+}
+
+type Hold struct {
+	Block entity.BlockEntity // the block that went into the hold
+}
+
+type Paused struct {
+	Paused bool // false when the game continues
+}
+
+// The game stopped for good, either topped out or with the goal reached
+type GameOver struct {
+	State int
+}
+
+func (PieceSpawned) event() {}
+func (PieceMoved) event()   {}
+func (PieceRotated) event() {}
+func (PieceLocked) event()  {}
+func (LinesCleared) event() {}
+func (LevelUp) event()      {}
+func (Hold) event()         {}
+func (Paused) event()       {}
+func (GameOver) event()     {}
+
+var CLEAR_NAMES []string = []string{"", "Single", "Double", "Triple", "Tetris"}
+
+// Name of the clear as the HUD shows it, e.g. T-Spin Double
+func (l LinesCleared) Type() string {
+	names := make([]string, 0, 3)
+	if l.PerfectClear {
+		names = append(names, "Perfect Clear")
+	}
+	if l.TSpin {
+		names = append(names, "T-Spin")
+	}
+	if l.Count > 0 {
+		names = append(names, CLEAR_NAMES[min(l.Count, len(CLEAR_NAMES)-1)])
+	}
+
+	return strings.Join(names, " ")
+}
+
+// Hands the events of a game to everything that subscribed, in the order they subscribed
+type Events struct {
+	handlers []func(Event)
+}
+
+func (e *Events) Subscribe(handler func(Event)) {
+	e.handlers = append(e.handlers, handler)
+}
+
+// Games built without New have nobody listening
+func (e *Events) publish(event Event) {
+	if e == nil {
+		return
+	}
+
+	for _, handler := range e.handlers {
+		handler(event)
+	}
+}
+
+// Subscribes to a single type of event, e.g. game.On(tg.Events, func(event game.LinesCleared) { ... })
+func On[T Event](events *Events, handler func(T)) {
+	events.Subscribe(func(event Event) {
+		if typed, ok := event.(T); ok {
+			handler(typed)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/matrix"
+	movegenerator "tetris/move_generator"
+)
+
+// Steers the pieces onto the given cells one after the other and soft drops the rest
+func playPlacements(tetrisGame *TetrisGame, targets []matrix.Matrix) {
+	var follower *movegenerator.Follower
+	var followed *entity.BlockEntity
+
+	for tetrisGame.State == PLAY && tetrisGame.Ticks < 10000 {
+		if tetrisGame.CurrentBlock == nil || tetrisGame.Pieces >= len(targets) {
+			tetrisGame.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN})
+			continue
+		}
+
+		// every spawn and hold brings in a new block
+		if followed != tetrisGame.CurrentBlock {
+			newFollower, err := movegenerator.NewFollower(tetrisGame.Board(), *tetrisGame.CurrentBlock, targets[tetrisGame.Pieces])
+			if err != nil {
+				panic(err.Error())
+			}
+			follower, followed = &newFollower, tetrisGame.CurrentBlock
+		}
+
+		event, err := follower.NextEvent(tetrisGame.Board(), *tetrisGame.CurrentBlock)
+		if err != nil {
+			panic(err.Error())
+		}
+		tetrisGame.Update(event)
+	}
+}
+
+func TestEventsOfAPerfectClear(t *testing.T) {
+	tetrisGame := newTestGame([]string{"GGGGGGGGG.", "GGGGGGGGG.", "GGGGGGGGG.", "GGGGGGGGG."}, entity.O, entity.I)
+	events := make([]string, 0)
+	tetrisGame.Events.Subscribe(func(event Event) {
+		// moves and rotations depend on the path the follower takes
+		if _, moved := event.(PieceMoved); !moved {
+			if _, rotated := event.(PieceRotated); !rotated {
+				events = append(events, fmt.Sprintf("%T", event))
+			}
+		}
+	})
+	clears := make([]LinesCleared, 0)
+	On(tetrisGame.Events, func(event LinesCleared) { clears = append(clears, event) })
+
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{Hold: true})
+	playPlacements(&tetrisGame, []matrix.Matrix{{{9, 16}, {9, 17}, {9, 18}, {9, 19}}})
+
+	// the sequence ran out once the I locked
+	expected := []string{"game.PieceSpawned", "game.Hold", "game.PieceSpawned", "game.PieceLocked", "game.LinesCleared", "game.GameOver"}
+	if !slices.Equal(events, expected) {
+		t.Errorf("Expected the events %v, found %v", expected, events)
+		t.Fail()
+	}
+
+	if len(clears) != 1 || clears[0].Count != 4 || clears[0].Type() != "Perfect Clear Tetris" {
+		t.Errorf("Expected a perfect clear tetris, found %v", clears)
+		t.Fail()
+	}
+}
+
+func TestCopiesOfAGameShareTheirSubscribers(t *testing.T) {
+	tetrisGame := newTestGame(nil, entity.O)
+	spawned := 0
+
+	// games get copied when they are returned by value, subscribing on either copy has to reach the other one
+	copied := tetrisGame
+	On(copied.Events, func(PieceSpawned) { spawned += 1 })
+
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	if spawned != 1 {
+		t.Errorf("Expected the spawn of the O, found %d spawns", spawned)
+		t.Fail()
+	}
+}
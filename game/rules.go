@@ -0,0 +1,22 @@
+package game
+
+// Settings that change how a game plays out, shared by everything that has to agree on them
+type Rules struct {
+	MaxWidth       int `json:"max_width"`
+	MaxHeight      int `json:"max_height"`
+	LineClearDelay int `json:"line_clear_delay"` // ticks full rows stay on the board before the next block, 0 clears them right away
+}
+
+var RULE_SETS map[string]Rules = map[string]Rules{
+	// same board the game opens with
+	"default": {MaxWidth: 10, MaxHeight: 20, LineClearDelay: 15},
+	// 10 by 20 board most other tetris games and bots use
+	"standard": {MaxWidth: 9, MaxHeight: 19},
+}
+
+func NewFromRules(rules Rules, seed int64) TetrisGame {
+	tetrisGame := NewHeadless(rules.MaxWidth, rules.MaxHeight, seed)
+	tetrisGame.LineClearDelay = rules.LineClearDelay
+
+	return tetrisGame
+}
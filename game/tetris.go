@@ -1,29 +1,44 @@
 package game
 
 import (
+	"errors"
 	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"tetris/board"
 	"tetris/collision"
 	"tetris/entity"
 	eventhandler "tetris/event_handler"
+	"tetris/matrix"
 	"tetris/spawner"
+	treecoordinate "tetris/tree_coordinate"
 	renderer "tetris/ui"
 	"time"
 )
 
 const (
-	PAUSE = 1
-	PLAY  = 2
-	LOSE  = 4
+	PAUSE    = 1
+	PLAY     = 2
+	LOSE     = 4
+	FINISHED = 8
 )
 
 const (
 	CHANGE_LEVEL_DURATION_SECOND = 60
+	TICKS_PER_SECOND             = 60
+)
+
+const (
+	NEXT_BLOCK_PREVIEW       = 3
+	DEFAULT_SOFT_DROP_FACTOR = 4
 )
 
 const (
 	MOVING_BLOCK   = 0
 	SPAWNING_BLOCK = 1
 	BLOCK_STOPS    = 2
+	CLEARING_LINES = 3
 )
 
 var DIRECTION_MAP map[int][2]int = map[int][2]int{
@@ -39,6 +54,12 @@ var LEVEL_SPEED map[int]float64 = map[int]float64{
 	4: 0.1,
 }
 
+// End condition and HUD of a game mode, the game stops once the goal is reached
+type Goal interface {
+	Finished(tg *TetrisGame) bool
+	Hud(tg *TetrisGame) []string
+}
+
 type TetrisGame struct {
 	MaxWitdh           int
 	MaxHeight          int
@@ -46,34 +67,107 @@ type TetrisGame struct {
 	BlockState         int
 	Score              int
 	Level              int
-	speedUpMultiplier  int
+	Lines              int
+	GarbageLines       int
+	TSpins             int
+	TSpinDoubles       int
+	PerfectClears      int
+	Pieces             int
+	Keys               int // presses while the game was running
+	Ticks              int
+	SoftDropFactor     int
+	LineClearDelay     int // ticks from a lock that clears lines to the next block, the full rows stay on the board meanwhile
+	clearingRows       []int
+	clearTicks         int
 	gainedScore        int
 	CurrentBlock       *entity.BlockEntity
+	NextBlocks         []entity.BlockEntity
+	HoldBlock          *entity.BlockEntity
+	holdUsed           bool
+	HoldDisabled       bool
+	lastMoveRotation   bool
+	hardDropped        bool
+	buffered           inputBuffer
+	retry              *retry
 	Spawner            spawner.BlockSpawner
 	CollisionDetector  collision.Collision
 	Renderer           renderer.Renderer
+	EventHandler       eventhandler.EventHandler
+	OnUpdate           func(tg *TetrisGame)
+	Events             *Events // every copy of the game publishes to the same subscribers
+	Goal               Goal
+	InitialBoard       *board.Board
+	Aim                matrix.Matrix
 	currentSpeed       float64 // could also probably use time, but to lazy for now
 	blockColors        [][]int
+	blockTypes         [][]int
 	blockProjectionPos [][2]float32
 	startTime          time.Time
 }
 
-func (tg *TetrisGame) Play() {
+// Prepares the game state without opening any window, so it can be driven headlessly through Update
+func (tg *TetrisGame) Start() {
 	tg.State = PLAY
 	tg.BlockState = SPAWNING_BLOCK
 	tg.blockColors = make([][]int, tg.MaxWitdh+1)
+	tg.blockTypes = make([][]int, tg.MaxWitdh+1)
 	tg.blockProjectionPos = make([][2]float32, 4)
 	tg.startTime = time.Now()
 
 	for i := range len(tg.blockColors) {
 		tg.blockColors[i] = make([]int, tg.MaxHeight+1)
+		tg.blockTypes[i] = make([]int, tg.MaxHeight+1)
+	}
+
+	if tg.InitialBoard != nil {
+		tg.fill(*tg.InitialBoard)
+	}
+}
+
+// Adds the blocks of the board to the ones already on the game
+func (tg *TetrisGame) fill(b board.Board) {
+	for y, row := range b.Cells {
+		for x, value := range row {
+			if value != board.EMPTY && tg.CollisionDetector.ValidLocation(x, y) {
+				tg.CollisionDetector.AddOccupiedBlocks(x, y)
+				tg.blockColors[x][y] = value
+				tg.blockTypes[x][y] = b.Piece(x, y)
+			}
+		}
+	}
+}
+
+// Takes the last placement back, e.g. from a PieceLocked subscriber. On the next update the board is put back
+// the way it was and block comes in again ahead of the queue. The counters keep the placement that was taken back.
+func (tg *TetrisGame) Retry(previous board.Board, block entity.BlockEntity) {
+	tg.retry = &retry{board: previous, block: block}
+}
+
+func (tg *TetrisGame) restore(r retry) {
+	tg.CollisionDetector.OccupiedBlocks = treecoordinate.New()
+	for x := range tg.blockColors {
+		clear(tg.blockColors[x])
+		clear(tg.blockTypes[x])
 	}
+	tg.fill(r.board)
+
+	tg.clearingRows = nil
+	tg.CurrentBlock = nil
+	tg.BlockState = SPAWNING_BLOCK
+	tg.NextBlocks = append([]entity.BlockEntity{r.block}, tg.NextBlocks...)
+}
+
+func (tg *TetrisGame) Play() {
+	tg.Start()
 
 	tg.Renderer.Init("Tetris")
 	defer tg.Renderer.Close()
 
 	for !tg.Renderer.ShouldClose() {
 		tg.Update(tg.ReceiveEvent())
+		if tg.OnUpdate != nil {
+			tg.OnUpdate(tg)
+		}
 		tg.Render()
 	}
 }
@@ -83,172 +177,645 @@ func (tg *TetrisGame) Continue() {
 }
 
 func (tg *TetrisGame) Update(event eventhandler.UpdateEvent) {
+	state, level := tg.State, tg.Level
+	// works while paused as well
+	if event.NextTheme {
+		tg.Renderer.NextTheme()
+	}
+	tg.update(event)
 
-	tg.Level = int(math.Min(4, time.Now().Sub(tg.startTime).Seconds()/float64(CHANGE_LEVEL_DURATION_SECOND)))
+	if tg.Level > level {
+		tg.Events.publish(LevelUp{Level: tg.Level})
+	}
 
-	if tg.State == PAUSE || tg.State == LOSE {
+	if tg.State == state {
 		return
 	}
 
-	if event.GameState == PAUSE {
+	if tg.State == PAUSE || state == PAUSE && tg.State == PLAY {
+		tg.Events.publish(Paused{Paused: tg.State == PAUSE})
+	} else if tg.State == LOSE || tg.State == FINISHED {
+		tg.Events.publish(GameOver{State: tg.State})
+	}
+}
+
+func (tg *TetrisGame) update(event eventhandler.UpdateEvent) {
+
+	// counted in ticks instead of wall time so that a headless game plays out the same way every time
+	tg.Level = int(math.Min(4, float64(tg.Ticks)/float64(CHANGE_LEVEL_DURATION_SECOND*TICKS_PER_SECOND)))
+
+	// a game that never started is paused as well and has no ticks yet
+	if tg.State == PAUSE && event.Pause && tg.Ticks > 0 {
+		tg.State = PLAY
+		return
+	}
+
+	if tg.State == PAUSE || tg.State == LOSE || tg.State == FINISHED {
+		return
+	}
+
+	if tg.Goal != nil && tg.Goal.Finished(tg) {
+		tg.State = FINISHED
+		return
+	}
+
+	if tg.retry != nil {
+		tg.restore(*tg.retry)
+		tg.retry = nil
+	}
+
+	tg.Ticks += 1
+	tg.Keys += event.Presses
+
+	if event.GameState == PAUSE || event.Pause {
 		tg.State = PAUSE
 	} else if tg.BlockState == SPAWNING_BLOCK {
-		block, err := tg.Spawner.Spawn()
-		if err != nil {
-			panic(err.Error())
+		tg.bufferInput(event)
+		tg.spawnNext()
+	} else if tg.BlockState == MOVING_BLOCK {
+		// several actions in the same tick always apply in this order
+		if event.Hold && tg.canHold() {
+			tg.hold()
 		}
-		tg.CurrentBlock = &block
-		tg.BlockState = MOVING_BLOCK
 
-		for i, location := range block.OccupiedPosition {
-			tg.blockProjectionPos[i][0] = float32(location[0])
-			tg.blockProjectionPos[i][1] = float32(tg.MaxHeight)
+		if tg.State == PLAY && tg.BlockState == MOVING_BLOCK {
+			tg.move(event)
 		}
 
-	} else if tg.BlockState == MOVING_BLOCK {
+		if event.HardDrop && tg.State == PLAY && tg.BlockState == MOVING_BLOCK {
+			tg.hardDrop()
+		}
+	} else if tg.BlockState == CLEARING_LINES {
+		tg.bufferInput(event)
+
+		// the next block takes the place of the rows on the last tick of the delay
+		tg.clearTicks -= 1
+		if tg.clearTicks <= 0 {
+			tg.clearRows()
+			tg.spawnNext()
+		}
+	} else if tg.BlockState == BLOCK_STOPS {
+		tg.bufferInput(event)
+
+		garbageLines := 0
+		tSpin := tg.tSpin()
 
-		tg.currentSpeed += LEVEL_SPEED[tg.Level]
-		if event.MovingDirection == eventhandler.DOWN {
-			tg.currentSpeed += float64(tg.speedUpMultiplier)*LEVEL_SPEED[tg.Level] - LEVEL_SPEED[tg.Level] // cancels out the previous addition
+		for _, location := range tg.CurrentBlock.OccupiedPosition {
+			tg.CollisionDetector.AddOccupiedBlocks(location[0], location[1])
+			tg.blockColors[location[0]][location[1]] = tg.CurrentBlock.Color
+			tg.blockTypes[location[0]][location[1]] = tg.CurrentBlock.EntityType
 		}
-		baseDirection := [2]int{0, 1}
-		direction, ok := DIRECTION_MAP[event.MovingDirection]
-		if ok {
-			baseDirection[0] = direction[0]
-			baseDirection[1] = direction[1]
+
+		// rows are cleared from the top down, clearing a row only moves the rows above it
+		rows := make([]int, 0, len(tg.CurrentBlock.OccupiedPosition))
+		for _, location := range tg.CurrentBlock.OccupiedPosition {
+			if !slices.Contains(rows, location[1]) {
+				rows = append(rows, location[1])
+			}
 		}
+		sort.Ints(rows)
+
+		// full rows are counted right away, they only leave the board once the line clear delay is over
+		tg.clearingRows = make([]int, 0, len(rows))
+		for _, y := range rows {
+			if tg.CollisionDetector.GetYCount(y) == tg.MaxWitdh+1 {
+				tg.clearingRows = append(tg.clearingRows, y)
+				if tg.garbageRow(y) {
+					garbageLines += 1
+				}
+			}
+		}
+		totalRemoveBlock := len(tg.clearingRows)
 
-		collisionOnSpawnPoint, collide, reachedBottom, outOfBounds := false, false, false, false
-		collideVertically := false
+		tg.gainedScore = totalRemoveBlock * tg.MaxWitdh
+		tg.Score += totalRemoveBlock * tg.MaxWitdh
+		tg.Lines += totalRemoveBlock
+		tg.GarbageLines += garbageLines
+		if tSpin {
+			tg.TSpins += 1
+			if totalRemoveBlock == 2 {
+				tg.TSpinDoubles += 1
+			}
+		}
+		perfectClear := totalRemoveBlock > 0 && len(tg.CollisionDetector.GetAllBlocks()) == totalRemoveBlock*(tg.MaxWitdh+1)
+		if perfectClear {
+			tg.PerfectClears += 1
+		}
+		tg.Pieces += 1
+
+		tg.Renderer.LockFlash(cells(tg.CurrentBlock.OccupiedPosition))
+		if totalRemoveBlock > 0 {
+			tg.Renderer.ClearRows(tg.clearingRows, time.Duration(tg.LineClearDelay)*time.Second/TICKS_PER_SECOND)
+		}
+
+		tg.Events.publish(PieceLocked{Block: *tg.CurrentBlock, HardDrop: tg.hardDropped})
+		if totalRemoveBlock > 0 || tSpin {
+			tg.Events.publish(LinesCleared{Count: totalRemoveBlock, TSpin: tSpin, PerfectClear: perfectClear, Garbage: garbageLines})
+		}
+
+		tg.BlockState = SPAWNING_BLOCK
+		tg.CurrentBlock = nil
+		tg.holdUsed = false
 
-		if tg.currentSpeed < 1 {
-			baseDirection[1] = 0
+		// the game only waits when there is something to clear
+		if totalRemoveBlock > 0 && tg.LineClearDelay > 0 {
+			tg.BlockState = CLEARING_LINES
+			tg.clearTicks = tg.LineClearDelay
 		} else {
-			tg.currentSpeed = 0
+			tg.clearRows()
 		}
+	}
+}
 
-		tg.CurrentBlock.RotateBlock(event.RotateDirection)
+func (tg *TetrisGame) spawnNext() {
+	block, ok := tg.nextBlock()
+	if !ok {
+		// a fixed sequence ran out, there is nothing left to play
+		tg.State = FINISHED
+		return
+	}
+	tg.spawn(block)
+	tg.applyBufferedInput()
+}
 
-		for _, location := range tg.CurrentBlock.OccupiedPosition {
-			// TODO: handle case for going down immediately
-			x, y := location[0]+baseDirection[0], location[1]+baseDirection[1]
-			reachedBottom = y >= tg.MaxHeight || reachedBottom
-			_, uy, _ := tg.CollisionDetector.GetNonBlockingPosition(location[0], location[1])
-			collideVertically = collideVertically || uy == y && y != -1
-			collide = (tg.CollisionDetector.Collide(x, y)) || collide
-			collisionOnSpawnPoint = (location[1] == 0 && collide) || collisionOnSpawnPoint
-			outOfBounds = outOfBounds || !tg.CollisionDetector.ValidLocation(x, y)
+// Takes the full rows of the last lock off the board
+func (tg *TetrisGame) clearRows() {
+	for _, y := range tg.clearingRows {
+		// TODO: handle node deletion properly
+		// pop all of the blocks from the tree
+		if err := tg.CollisionDetector.RemoveBlock(y); err == nil {
+			tg.removeCells(y)
 		}
+	}
 
-		if collisionOnSpawnPoint {
-			tg.State = LOSE
-			return
+	tg.clearingRows = nil
+}
+
+// Rotation, shifting and gravity of the current block
+func (tg *TetrisGame) move(event eventhandler.UpdateEvent) {
+	// an auto repeat rate of 0 slides the block to the wall before gravity and rotation apply
+	if direction, ok := DIRECTION_MAP[event.MovingDirection]; ok && event.ToWall {
+		moved := false
+		for tg.fits(direction[0], 0) {
+			tg.CurrentBlock.MoveBlock([2]int{direction[0], 0})
+			tg.lastMoveRotation = false
+			moved = true
 		}
+		if moved {
+			tg.Events.publish(PieceMoved{Block: *tg.CurrentBlock})
+		}
+		event.MovingDirection = 0
+	}
 
-		if (collide || outOfBounds) && !(collideVertically) {
-			if event.RotateDirection == entity.CLOCKWISE {
-				tg.CurrentBlock.RotateBlock(entity.ANTI_CLOCKWISE)
-			} else if event.RotateDirection == entity.ANTI_CLOCKWISE {
-				tg.CurrentBlock.RotateBlock(entity.CLOCKWISE)
-			}
-			return
+	tg.currentSpeed += LEVEL_SPEED[tg.Level]
+	if event.MovingDirection == eventhandler.DOWN {
+		tg.currentSpeed += float64(tg.SoftDropFactor)*LEVEL_SPEED[tg.Level] - LEVEL_SPEED[tg.Level] // cancels out the previous addition
+	}
+	baseDirection := [2]int{0, 1}
+	direction, ok := DIRECTION_MAP[event.MovingDirection]
+	if ok {
+		baseDirection[0] = direction[0]
+		baseDirection[1] = direction[1]
+	}
+
+	collisionOnSpawnPoint, collide, reachedBottom, outOfBounds := false, false, false, false
+	collideVertically := false
+
+	if tg.currentSpeed < 1 {
+		baseDirection[1] = 0
+	} else {
+		tg.currentSpeed = 0
+	}
+
+	tg.CurrentBlock.RotateBlock(event.RotateDirection)
+
+	for _, location := range tg.CurrentBlock.OccupiedPosition {
+		// TODO: handle case for going down immediately
+		x, y := location[0]+baseDirection[0], location[1]+baseDirection[1]
+		reachedBottom = y >= tg.MaxHeight || reachedBottom
+		_, uy, _ := tg.CollisionDetector.GetNonBlockingPosition(location[0], location[1])
+		collideVertically = collideVertically || uy == y && y != -1
+		collide = (tg.CollisionDetector.Collide(x, y)) || collide
+		collisionOnSpawnPoint = (location[1] == 0 && collide) || collisionOnSpawnPoint
+		outOfBounds = outOfBounds || !tg.CollisionDetector.ValidLocation(x, y)
+	}
+
+	if collisionOnSpawnPoint {
+		tg.State = LOSE
+		return
+	}
+
+	if (collide || outOfBounds) && !(collideVertically) {
+		if event.RotateDirection == entity.CLOCKWISE {
+			tg.CurrentBlock.RotateBlock(entity.ANTI_CLOCKWISE)
+		} else if event.RotateDirection == entity.ANTI_CLOCKWISE {
+			tg.CurrentBlock.RotateBlock(entity.CLOCKWISE)
 		}
+		return
+	}
 
-		if !outOfBounds && !collide {
-			tg.CurrentBlock.MoveBlock(baseDirection)
+	if _, ok := entity.ORIENTATION_ROTATION[event.RotateDirection]; ok {
+		tg.Events.publish(PieceRotated{Block: *tg.CurrentBlock})
+	}
 
-			maxUpperBoundY, defaultUpperYMax, yUpperMax := 1000000, -1, -1
-			for _, location := range tg.CurrentBlock.OccupiedPosition {
-				_, uy, _ := tg.CollisionDetector.GetNonBlockingPosition(location[0], location[1])
+	// gravity pulling the block down doesn't undo a spin, only the player moving it does
+	playerMoved := baseDirection[0] != 0 || event.MovingDirection == eventhandler.DOWN && baseDirection[1] != 0
+	if !outOfBounds && !collide && playerMoved {
+		tg.lastMoveRotation = false
+	} else if event.RotateDirection != 0 {
+		tg.lastMoveRotation = true
+	}
 
-				if maxUpperBoundY > uy-1 && uy != -1 {
-					maxUpperBoundY = uy - 1
-					yUpperMax = location[1]
-				} else if maxUpperBoundY == uy-1 && yUpperMax < location[1] {
-					yUpperMax = location[1]
-				}
+	if !outOfBounds && !collide {
+		tg.CurrentBlock.MoveBlock(baseDirection)
+		if baseDirection[0] != 0 {
+			tg.Events.publish(PieceMoved{Block: *tg.CurrentBlock})
+		}
 
-				if defaultUpperYMax <= location[1] {
-					defaultUpperYMax = location[1]
-				}
-			}
+		maxUpperBoundY, defaultUpperYMax, yUpperMax := 1000000, -1, -1
+		for _, location := range tg.CurrentBlock.OccupiedPosition {
+			_, uy, _ := tg.CollisionDetector.GetNonBlockingPosition(location[0], location[1])
 
-			if maxUpperBoundY == 1000000 {
-				maxUpperBoundY = tg.MaxHeight
-				yUpperMax = defaultUpperYMax
+			if maxUpperBoundY > uy-1 && uy != -1 {
+				maxUpperBoundY = uy - 1
+				yUpperMax = location[1]
+			} else if maxUpperBoundY == uy-1 && yUpperMax < location[1] {
+				yUpperMax = location[1]
 			}
 
-			for i, location := range tg.CurrentBlock.OccupiedPosition {
-				tg.blockProjectionPos[i][0] = float32(location[0])
-				tg.blockProjectionPos[i][1] = float32(maxUpperBoundY - (yUpperMax - location[1]))
+			if defaultUpperYMax <= location[1] {
+				defaultUpperYMax = location[1]
 			}
+		}
 
+		if maxUpperBoundY == 1000000 {
+			maxUpperBoundY = tg.MaxHeight
+			yUpperMax = defaultUpperYMax
 		}
 
-		if reachedBottom || collideVertically {
-			tg.BlockState = BLOCK_STOPS
+		for i, location := range tg.CurrentBlock.OccupiedPosition {
+			tg.blockProjectionPos[i][0] = float32(location[0])
+			tg.blockProjectionPos[i][1] = float32(maxUpperBoundY - (yUpperMax - location[1]))
 		}
 
-	} else if tg.BlockState == BLOCK_STOPS {
+	}
 
-		totalRemoveBlock := 0
+	if reachedBottom || collideVertically {
+		tg.BlockState = BLOCK_STOPS
+	}
+}
 
-		for _, location := range tg.CurrentBlock.OccupiedPosition {
-			tg.CollisionDetector.AddOccupiedBlocks(location[0], location[1])
-			tg.blockColors[location[0]][location[1]] = tg.CurrentBlock.Color
+// Moves the block straight down as far as it goes, it locks on the next update
+func (tg *TetrisGame) hardDrop() {
+	start, distance := cells(tg.CurrentBlock.OccupiedPosition), 0
+	for tg.fits(0, 1) {
+		tg.CurrentBlock.MoveBlock([2]int{0, 1})
+		tg.lastMoveRotation = false
+		distance += 1
+	}
+	tg.Renderer.HardDropTrail(start, float32(distance), tg.CurrentBlock.Color)
+
+	tg.hardDropped = true
+	tg.BlockState = BLOCK_STOPS
+}
+
+func (tg *TetrisGame) fits(dx, dy int) bool {
+	for _, location := range tg.CurrentBlock.OccupiedPosition {
+		x, y := location[0]+dx, location[1]+dy
+		// a freshly rotated block can stick out above the board
+		if x < 0 || x > tg.MaxWitdh || y > tg.MaxHeight || tg.CollisionDetector.Collide(x, y) {
+			return false
 		}
+	}
 
-		for _, location := range tg.CurrentBlock.OccupiedPosition {
-			y := location[1]
-			// TODO: handle node deletion properly
-			totalCoordinate := tg.CollisionDetector.GetYCount(y)
-
-			if totalCoordinate == tg.MaxWitdh+1 {
-				// pop all of the blocks from the tree
-				error := tg.CollisionDetector.RemoveBlock(y)
-				if error == nil {
-					totalRemoveBlock += 1
-				}
-			}
+	return true
+}
+
+func (tg *TetrisGame) garbageRow(y int) bool {
+	for x := range tg.blockTypes {
+		if tg.blockTypes[x][y] == board.GARBAGE {
+			return true
 		}
+	}
 
-		tg.gainedScore = totalRemoveBlock * tg.MaxWitdh
-		tg.Score += totalRemoveBlock * tg.MaxWitdh
-		tg.BlockState = SPAWNING_BLOCK
-		tg.CurrentBlock = nil
+	return false
+}
+
+// Moves the colors and types above the cleared row down with their blocks
+func (tg *TetrisGame) removeCells(y int) {
+	for _, columns := range [][][]int{tg.blockColors, tg.blockTypes} {
+		for _, column := range columns {
+			copy(column[1:y+1], column[:y])
+			column[0] = 0
+		}
 	}
 }
 
-func (tg *TetrisGame) Render() {
+// Takes the first block of the preview queue and tops the queue back up, fails once a fixed sequence ran out
+func (tg *TetrisGame) nextBlock() (entity.BlockEntity, bool) {
+	for len(tg.NextBlocks) <= NEXT_BLOCK_PREVIEW {
+		block, err := tg.Spawner.Spawn()
+		if errors.Is(err, spawner.ErrSequenceEnd) {
+			break
+		} else if err != nil {
+			panic(err.Error())
+		}
+		tg.NextBlocks = append(tg.NextBlocks, block)
+	}
 
-	if tg.State == PLAY {
-		blocks := tg.CollisionDetector.GetAllBlocks()
-		if tg.CurrentBlock != nil {
-			for _, location := range tg.CurrentBlock.OccupiedPosition {
-				blocks = append(blocks, [2]float32{float32(location[0]), float32(location[1])})
-				tg.blockColors[location[0]][location[1]] = tg.CurrentBlock.Color
-			}
+	if len(tg.NextBlocks) == 0 {
+		return entity.BlockEntity{}, false
+	}
+
+	block := tg.NextBlocks[0]
+	tg.NextBlocks = tg.NextBlocks[1:]
+
+	return block, true
+}
+
+// A T that locks right after rotating, with at least three of the corners around its center taken
+func (tg *TetrisGame) tSpin() bool {
+	if tg.CurrentBlock.EntityType != entity.T || !tg.lastMoveRotation {
+		return false
+	}
+
+	center := tg.CurrentBlock.OccupiedPosition[entity.BLOCK_CENTER[entity.T]]
+	takenCorners := 0
+
+	for _, corner := range [][2]int{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+		x, y := center[0]+corner[0], center[1]+corner[1]
+		if !tg.CollisionDetector.ValidLocation(x, y) || tg.CollisionDetector.Collide(x, y) {
+			takenCorners += 1
 		}
-		projectionColor := -1
-		if tg.CurrentBlock != nil {
-			projectionColor = tg.CurrentBlock.Color
+	}
+
+	return takenCorners >= 3
+}
+
+func (tg *TetrisGame) spawn(block entity.BlockEntity) {
+	tg.CurrentBlock = &block
+	tg.BlockState = MOVING_BLOCK
+	tg.currentSpeed = 0
+	tg.lastMoveRotation = false
+	tg.hardDropped = false
+
+	// the new block has nowhere to go, otherwise it would be stuck on the spawn point forever
+	for _, location := range block.OccupiedPosition {
+		if tg.CollisionDetector.Collide(location[0], location[1]) {
+			tg.State = LOSE
+			return
 		}
-		tg.Renderer.RenderPlay(blocks, tg.blockColors, tg.blockProjectionPos, projectionColor, tg.gainedScore, tg.Level, tg.Score, time.Now().Sub(tg.startTime))
+	}
+
+	for i, location := range block.OccupiedPosition {
+		tg.blockProjectionPos[i][0] = float32(location[0])
+		tg.blockProjectionPos[i][1] = float32(tg.MaxHeight)
+	}
+
+	// the current block moves from here on, subscribers keep the spot it spawned on
+	spawned := entity.BlockEntity{EntityType: block.EntityType, Color: block.Color, OccupiedPosition: matrix.Copy(block.OccupiedPosition)}
+	tg.Events.publish(PieceSpawned{Block: spawned})
+}
+
+// Placement taken back, see Retry
+type retry struct {
+	board board.Board
+	block entity.BlockEntity
+}
+
+// Rotation and hold pressed while there was no block to take them, they apply to the next block as it spawns
+type inputBuffer struct {
+	rotation int
+	hold     bool
+}
+
+func (tg *TetrisGame) bufferInput(event eventhandler.UpdateEvent) {
+	if event.RotateDirection != 0 {
+		tg.buffered.rotation = event.RotateDirection
+	}
+	tg.buffered.hold = tg.buffered.hold || event.Hold
+}
+
+// Initial hold comes first so that an initial rotation turns the block that ends up in play
+func (tg *TetrisGame) applyBufferedInput() {
+	buffered := tg.buffered
+	tg.buffered = inputBuffer{}
+
+	if buffered.hold && tg.canHold() && tg.State == PLAY {
+		tg.hold()
+	}
+
+	if buffered.rotation != 0 && tg.State == PLAY && tg.BlockState == MOVING_BLOCK {
+		tg.CurrentBlock.RotateBlock(buffered.rotation)
+		if tg.placeable() {
+			tg.lastMoveRotation = true
+			tg.Events.publish(PieceRotated{Block: *tg.CurrentBlock})
+		} else if buffered.rotation == entity.CLOCKWISE {
+			tg.CurrentBlock.RotateBlock(entity.ANTI_CLOCKWISE)
+		} else {
+			tg.CurrentBlock.RotateBlock(entity.CLOCKWISE)
+		}
+	}
+}
+
+func (tg *TetrisGame) canHold() bool {
+	return !tg.holdUsed && !tg.HoldDisabled
+}
+
+// Whether the current block sits inside the board without overlapping anything
+func (tg *TetrisGame) placeable() bool {
+	for _, location := range tg.CurrentBlock.OccupiedPosition {
+		if !tg.CollisionDetector.ValidLocation(location[0], location[1]) || tg.CollisionDetector.Collide(location[0], location[1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Swaps the current block with the held one, only once until the next block locks
+func (tg *TetrisGame) hold() {
+	heldBlock := tg.HoldBlock
+
+	currentBlock, err := entity.New(tg.CurrentBlock.EntityType, tg.CurrentBlock.Color, [2]int{0, 0})
+	if err != nil {
+		panic(err.Error())
+	}
+
+	if heldBlock == nil {
+		block, ok := tg.nextBlock()
+		if !ok {
+			return
+		}
+
+		tg.holdUsed = true
+		tg.HoldBlock = &currentBlock
+		tg.Events.publish(Hold{Block: currentBlock})
+		tg.spawn(block)
+		return
+	}
+
+	block, err := tg.Spawner.SpawnBlock(heldBlock.EntityType)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	tg.holdUsed = true
+	tg.HoldBlock = &currentBlock
+	tg.Events.publish(Hold{Block: currentBlock})
+	tg.spawn(block)
+}
+
+func (tg *TetrisGame) Render() {
+	if tg.State == PLAY && tg.Goal != nil {
+		tg.Renderer.BeginFrame()
+		tg.DrawBoard()
+		tg.Renderer.RenderHud(tg.Goal.Hud(tg))
+		tg.Renderer.EndFrame()
+	} else if tg.State == PLAY {
+		blocks, projectionColor := tg.visibleBlocks()
+		tg.Renderer.RenderPlay(blocks, tg.blockColors, tg.projection(), projectionColor, tg.gainedScore, tg.Level, tg.Score, time.Now().Sub(tg.startTime))
 		tg.gainedScore = 0
+	} else if tg.State == PAUSE {
+		tg.Renderer.RenderFinished("Paused", []string{"Press pause to continue"})
 	} else if tg.State == LOSE {
 		tg.Renderer.RenderLose(tg.Score)
+	} else if tg.State == FINISHED && tg.Goal != nil {
+		tg.Renderer.RenderFinished("Finished", tg.Goal.Hud(tg))
+	} else if tg.State == FINISHED {
+		tg.Renderer.RenderFinished("Finished", []string{})
+	}
+}
+
+// Only draws the board, the caller owns the frame. Used when several games share a window
+func (tg *TetrisGame) DrawBoard() {
+	blocks, projectionColor := tg.visibleBlocks()
+	tg.Renderer.DrawBoard(blocks, tg.blockColors, tg.projection(), projectionColor)
+}
+
+// Outline of where the block lands, or of the placement the player aims at when one is set
+func (tg *TetrisGame) projection() [][2]float32 {
+	if tg.Aim == nil {
+		return tg.blockProjectionPos
+	}
+
+	projection := make([][2]float32, len(tg.Aim))
+	for i, location := range tg.Aim {
+		projection[i] = [2]float32{float32(location[0]), float32(location[1])}
+	}
+
+	return projection
+}
+
+// Locked blocks together with the current one, and the color of the projection
+func (tg *TetrisGame) visibleBlocks() ([][2]float32, int) {
+	blocks := tg.CollisionDetector.GetAllBlocks()
+	projectionColor := -1
+
+	if tg.CurrentBlock != nil {
+		// gravity moves the block a whole row at once, in between it is drawn on its way down
+		fall := float32(0)
+		if tg.BlockState == MOVING_BLOCK && tg.fits(0, 1) {
+			fall = float32(tg.currentSpeed)
+		}
+
+		for _, location := range tg.CurrentBlock.OccupiedPosition {
+			blocks = append(blocks, [2]float32{float32(location[0]), float32(location[1]) + fall})
+			tg.blockColors[location[0]][location[1]] = tg.CurrentBlock.Color
+		}
+		projectionColor = tg.CurrentBlock.Color
+	}
+
+	return blocks, projectionColor
+}
+
+func cells(positions matrix.Matrix) [][2]float32 {
+	converted := make([][2]float32, len(positions))
+	for i, location := range positions {
+		converted[i] = [2]float32{float32(location[0]), float32(location[1])}
+	}
+
+	return converted
+}
+
+// Pushes garbage rows in from the bottom, holes[0] being the hole of the lowest row. Only call it between two blocks,
+// the current block is not moved. Returns true when the stack got pushed over the top, the game is lost then.
+func (tg *TetrisGame) AddGarbage(holes []int) bool {
+	if len(holes) == 0 {
+		return false
+	}
+
+	tg.blockColors = tg.pushColumns(tg.blockColors, holes, entity.GRAY)
+	tg.blockTypes = tg.pushColumns(tg.blockTypes, holes, board.GARBAGE)
+
+	toppedOut := tg.CollisionDetector.InsertGarbage(holes)
+	if toppedOut && tg.State != LOSE {
+		tg.State = LOSE
+		tg.Events.publish(GameOver{State: LOSE})
 	}
+
+	return toppedOut
+}
+
+// Columns moved up by the garbage rows, with the garbage value everywhere but in the holes
+func (tg *TetrisGame) pushColumns(columns [][]int, holes []int, garbage int) [][]int {
+	for x, column := range columns {
+		movedColumn := make([]int, len(column))
+		for y := len(holes); y < len(column); y++ {
+			movedColumn[y-len(holes)] = column[y]
+		}
+
+		for i, hole := range holes {
+			if y := tg.MaxHeight - i; y >= 0 && x != hole {
+				movedColumn[y] = garbage
+			}
+		}
+		columns[x] = movedColumn
+	}
+
+	return columns
 }
 
 func (tg TetrisGame) ReceiveEvent() eventhandler.UpdateEvent {
+	if tg.EventHandler != nil {
+		return tg.EventHandler.HandleEvent()
+	}
+
 	return eventhandler.HandleEvent()
 }
 
+// Snapshot of the locked blocks, each occupied cell holds the color and the type of the block
+func (tg TetrisGame) Board() board.Board {
+	currentBoard := board.New(tg.MaxWitdh+1, tg.MaxHeight+1)
+
+	for _, location := range tg.CollisionDetector.GetAllBlocks() {
+		x, y := int(location[0]), int(location[1])
+		if !currentBoard.ValidLocation(x, y) {
+			continue
+		}
+
+		currentBoard.Set(x, y, 0, board.EMPTY)
+		if x < len(tg.blockColors) && y < len(tg.blockColors[x]) {
+			currentBoard.Set(x, y, tg.blockColors[x][y], tg.blockTypes[x][y])
+		}
+	}
+
+	return currentBoard
+}
+
+// Builds a game that is never rendered, the same seed always gives the same sequence of blocks
+func NewHeadless(MaxWidth, MaxHeight int, seed int64) TetrisGame {
+	collisionDetector := collision.Collision{MaxWitdh: MaxWidth, MaxHeight: MaxHeight, OccupiedBlocks: treecoordinate.New()}
+	spawnerBlock := spawner.BlockSpawner{MaxWidth: MaxWidth, Randomizer: *rand.New(rand.NewSource(seed))}
+
+	return New(MaxWidth, MaxHeight, collisionDetector, spawnerBlock, renderer.Renderer{}, DEFAULT_SOFT_DROP_FACTOR, 0)
+}
+
 func New(MaxWidth, MaxHeight int,
 	CollisionDetector collision.Collision,
 	Spawner spawner.BlockSpawner,
 	Renderer renderer.Renderer,
-	speedUpMultiplier int,
+	softDropFactor int,
 	level int) TetrisGame {
 	return TetrisGame{
 		MaxWitdh:          MaxWidth,
@@ -259,6 +826,7 @@ func New(MaxWidth, MaxHeight int,
 		Level:             level,
 		State:             PAUSE,
 		Renderer:          Renderer,
-		speedUpMultiplier: speedUpMultiplier,
+		SoftDropFactor:    softDropFactor,
+		Events:            &Events{},
 	}
 }
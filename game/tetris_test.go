@@ -1,16 +1,21 @@
 package game
 
 import (
-	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
+	"tetris/board"
 	"tetris/collision"
+	"tetris/entity"
 	eventhandler "tetris/event_handler"
 	"tetris/matrix"
 	"tetris/spawner"
 	treecoordinate "tetris/tree_coordinate"
+	renderer "tetris/ui"
 )
 
+var FALL eventhandler.UpdateEvent = eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN}
+
 func TestUpdateSpawningBlock(t *testing.T) {
 
 	colisionDetector := collision.Collision{
@@ -25,12 +30,12 @@ func TestUpdateSpawningBlock(t *testing.T) {
 		CollisionDetector: colisionDetector,
 		Spawner:           spawnerBlock,
 		BlockState:        SPAWNING_BLOCK,
-		BlockSpeed:        1,
+		SoftDropFactor:    40, // soft dropping moves the block a row every update
 		State:             PAUSE,
 	}
-	game.Continue()
+	game.Start()
 
-	game.Update(eventhandler.UpdateEvent{})
+	game.Update(FALL)
 
 	if game.CurrentBlock == nil {
 		t.Error("Current block should not be nil")
@@ -58,15 +63,15 @@ func TestUpdateMovingBlock(t *testing.T) {
 		CollisionDetector: colisionDetector,
 		Spawner:           spawnerBlock,
 		BlockState:        SPAWNING_BLOCK,
-		BlockSpeed:        1,
+		SoftDropFactor:    40, // soft dropping moves the block a row every update
 		State:             PAUSE,
 	}
 
-	game.Continue()
+	game.Start()
 
-	game.Update(eventhandler.UpdateEvent{})
+	game.Update(FALL)
 	previousState := matrix.Copy(game.CurrentBlock.OccupiedPosition)
-	game.Update(eventhandler.UpdateEvent{})
+	game.Update(FALL)
 
 	allTheSame := true
 	currentState := game.CurrentBlock.OccupiedPosition
@@ -97,14 +102,14 @@ func TestUpdateMovingBlockUntilDownShouldStop(t *testing.T) {
 		CollisionDetector: colisionDetector,
 		Spawner:           spawnerBlock,
 		BlockState:        SPAWNING_BLOCK,
-		BlockSpeed:        1,
+		SoftDropFactor:    40, // soft dropping moves the block a row every update
 		State:             PAUSE,
 	}
 
-	game.Continue()
+	game.Start()
 
 	for range 100 {
-		game.Update(eventhandler.UpdateEvent{})
+		game.Update(FALL)
 	}
 
 	if game.BlockState != BLOCK_STOPS {
@@ -112,7 +117,7 @@ func TestUpdateMovingBlockUntilDownShouldStop(t *testing.T) {
 		t.Fail()
 	}
 
-	game.Update(eventhandler.UpdateEvent{})
+	game.Update(FALL)
 
 	totalBlockCount := game.CollisionDetector.GetTotalCount()
 	if totalBlockCount != 4 {
@@ -136,10 +141,10 @@ func TestUpdateMovingBlockOutOfBoundsShouldStayTheSame(t *testing.T) {
 		CollisionDetector: colisionDetector,
 		Spawner:           spawnerBlock,
 		BlockState:        SPAWNING_BLOCK,
-		BlockSpeed:        1,
+		SoftDropFactor:    40, // soft dropping moves the block a row every update
 		State:             PAUSE,
 	}
-	game.Continue()
+	game.Start()
 
 	for range 34 {
 		game.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.RIGHT})
@@ -167,36 +172,36 @@ func TestUpdateMovingCollidingWithExistingBlockShouldStop(t *testing.T) {
 		CollisionDetector: colisionDetector,
 		Spawner:           spawnerBlock,
 		BlockState:        SPAWNING_BLOCK,
-		BlockSpeed:        1,
+		SoftDropFactor:    40, // soft dropping moves the block a row every update
 		State:             PAUSE,
 	}
-	game.Continue()
+	game.Start()
 
 	// spawns the current block
-	game.Update(eventhandler.UpdateEvent{})
+	game.Update(FALL)
 
 	previousState := matrix.Copy(game.CurrentBlock.OccupiedPosition)
 
 	for range 99 {
-		game.Update(eventhandler.UpdateEvent{})
+		game.Update(FALL)
 	}
 
 	if game.BlockState != BLOCK_STOPS {
 		t.Errorf("Block state should have stop after 100 iteration")
 		t.Fail()
 	}
-	game.Update(eventhandler.UpdateEvent{})
+	game.Update(FALL)
 
 	if game.BlockState != SPAWNING_BLOCK {
 		t.Errorf("Game state after stopping the block should be spawning a new block")
 		t.Fail()
 	}
 
-	game.Update(eventhandler.UpdateEvent{})
+	game.Update(FALL)
 	game.CurrentBlock.OccupiedPosition = previousState
 
 	for range 98 {
-		game.Update(eventhandler.UpdateEvent{})
+		game.Update(FALL)
 	}
 
 	if game.BlockState != BLOCK_STOPS {
@@ -219,14 +224,16 @@ func TestStoppingBlockShouldPopBlockIfAllOccupied(t *testing.T) {
 		CollisionDetector: colisionDetector,
 		Spawner:           spawnerBlock,
 		BlockState:        SPAWNING_BLOCK,
-		BlockSpeed:        1,
+		SoftDropFactor:    40, // soft dropping moves the block a row every update
 		State:             PAUSE,
 	}
-	game.Continue()
+	game.Start()
+	// the I blocks fill the first 16 columns of the bottom row, the last column is taken already
+	game.CollisionDetector.AddOccupiedBlocks(16, 100)
 
 	i := 0
 	for i <= 12 {
-		game.Update(eventhandler.UpdateEvent{})
+		game.Update(FALL)
 		game.CurrentBlock.OccupiedPosition[0][0] = i
 		game.CurrentBlock.OccupiedPosition[1][0] = i + 1
 		game.CurrentBlock.OccupiedPosition[2][0] = i + 2
@@ -240,10 +247,10 @@ func TestStoppingBlockShouldPopBlockIfAllOccupied(t *testing.T) {
 		t.Log(game.CurrentBlock.OccupiedPosition.ToString())
 
 		for range 101 {
-			game.Update(eventhandler.UpdateEvent{})
+			game.Update(FALL)
 		}
 
-		game.Update(eventhandler.UpdateEvent{})
+		game.Update(FALL)
 
 		t.Log(game.CurrentBlock.OccupiedPosition.ToString())
 		i += 4
@@ -261,3 +268,202 @@ func TestStoppingBlockShouldPopBlockIfAllOccupied(t *testing.T) {
 		}
 	}
 }
+
+// Game on the standard board with the rows at its bottom and a fixed sequence of pieces
+func newTestGame(rows []string, pieces ...int) TetrisGame {
+	rules := RULE_SETS["standard"]
+	tetrisGame := NewFromRules(rules, 1)
+
+	initialBoard, err := board.ParseRows(rows, rules.MaxWidth+1, rules.MaxHeight+1)
+	if err != nil {
+		panic(err.Error())
+	}
+	tetrisGame.InitialBoard = &initialBoard
+	tetrisGame.Spawner.Sequence = spawner.NewSequence(pieces)
+
+	return tetrisGame
+}
+
+func TestHardDrop(t *testing.T) {
+	tetrisGame := newTestGame([]string{"GGG...GGGG"}, entity.O, entity.I)
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{HardDrop: true})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	if tetrisGame.Pieces != 1 || tetrisGame.Ticks != 3 {
+		t.Errorf("Hard drop should lock the O right away, found %d pieces after %d ticks", tetrisGame.Pieces, tetrisGame.Ticks)
+		t.FailNow()
+	}
+
+	if rows := tetrisGame.Board().StackRows(); len(rows) > 3 {
+		t.Errorf("The O should land on the stack, found\n%s", strings.Join(rows, "\n"))
+		t.Fail()
+	}
+}
+
+func TestPauseAndContinue(t *testing.T) {
+	tetrisGame := newTestGame(nil, entity.O)
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{Pause: true})
+	tetrisGame.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN})
+
+	if tetrisGame.State != PAUSE || tetrisGame.Ticks != 2 {
+		t.Errorf("Paused game should not go on, found state %d after %d ticks", tetrisGame.State, tetrisGame.Ticks)
+		t.FailNow()
+	}
+
+	tetrisGame.Update(eventhandler.UpdateEvent{Pause: true})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	if tetrisGame.State != PLAY || tetrisGame.Ticks != 3 {
+		t.Errorf("Pausing again should continue the game, found state %d after %d ticks", tetrisGame.State, tetrisGame.Ticks)
+		t.Fail()
+	}
+}
+
+func TestSlideToTheWall(t *testing.T) {
+	tetrisGame := newTestGame([]string{"........G."}, entity.O)
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.RIGHT, ToWall: true})
+
+	if x := tetrisGame.CurrentBlock.OccupiedPosition[1][0]; x != 9 {
+		t.Errorf("The O should slide to the right wall, found it at %v", tetrisGame.CurrentBlock.OccupiedPosition)
+		t.Fail()
+	}
+
+	tetrisGame.Update(eventhandler.UpdateEvent{HardDrop: true})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	expected := "........OO\n........OO\n........G."
+	if rows := strings.Join(tetrisGame.Board().StackRows(), "\n"); rows != expected {
+		t.Errorf("Expected the board\n%s\nfound\n%s", expected, rows)
+		t.Fail()
+	}
+}
+
+// Rows the current block spans, 3 for a T standing upright
+func blockHeight(tetrisGame *TetrisGame) int {
+	top, bottom := 1000, -1
+	for _, location := range tetrisGame.CurrentBlock.OccupiedPosition {
+		top, bottom = min(top, location[1]), max(bottom, location[1])
+	}
+
+	return bottom - top + 1
+}
+
+func TestInitialRotationAndHold(t *testing.T) {
+	tetrisGame := newTestGame(nil, entity.O, entity.O, entity.T)
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{HardDrop: true})
+
+	// both are pressed while the first O locks, the second O goes to hold and the T spawns turned
+	tetrisGame.Update(eventhandler.UpdateEvent{Hold: true})
+	tetrisGame.Update(eventhandler.UpdateEvent{RotateDirection: entity.CLOCKWISE})
+
+	if tetrisGame.CurrentBlock.EntityType != entity.T || tetrisGame.HoldBlock == nil || tetrisGame.HoldBlock.EntityType != entity.O {
+		t.Error("Initial hold should bring in the T")
+		t.FailNow()
+	}
+
+	if height := blockHeight(&tetrisGame); height != 3 {
+		t.Errorf("Initial rotation should turn the T upright, found it %d rows high", height)
+		t.Fail()
+	}
+}
+
+func TestActionsOfOneTickApplyInOrder(t *testing.T) {
+	tetrisGame := newTestGame(nil, entity.O, entity.T)
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{Hold: true, RotateDirection: entity.CLOCKWISE, HardDrop: true})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	if tetrisGame.Pieces != 1 || tetrisGame.HoldBlock == nil || tetrisGame.HoldBlock.EntityType != entity.O {
+		t.Errorf("Hold, rotation and hard drop should all apply, found %d pieces", tetrisGame.Pieces)
+		t.FailNow()
+	}
+
+	if rows := tetrisGame.Board().StackRows(); len(rows) != 3 {
+		t.Errorf("The T should be turned before it drops, found\n%s", strings.Join(rows, "\n"))
+		t.Fail()
+	}
+}
+
+func TestLineClearDelay(t *testing.T) {
+	tetrisGame := newTestGame([]string{"GGGGGGGG..", "GGGGGGGG.."}, entity.O, entity.I, entity.O)
+	tetrisGame.LineClearDelay = 5
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.RIGHT, ToWall: true, HardDrop: true})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	// the lines count as soon as the block locks, the rows wait on the board
+	if tetrisGame.Lines != 2 || tetrisGame.BlockState != CLEARING_LINES || len(tetrisGame.Board().StackRows()) != 2 {
+		t.Errorf("Expected 2 full rows on the board, found %d lines and\n%s", tetrisGame.Lines, strings.Join(tetrisGame.Board().StackRows(), "\n"))
+		t.FailNow()
+	}
+
+	tetrisGame.Update(eventhandler.UpdateEvent{Hold: true})
+	for range 3 {
+		tetrisGame.Update(eventhandler.UpdateEvent{})
+	}
+
+	if tetrisGame.CurrentBlock != nil {
+		t.Error("Next block should wait for the line clear delay")
+		t.FailNow()
+	}
+
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	if tetrisGame.CurrentBlock == nil || len(tetrisGame.Board().StackRows()) != 0 {
+		t.Errorf("Rows should clear and the next block spawn once the delay is over, found\n%s", strings.Join(tetrisGame.Board().StackRows(), "\n"))
+		t.FailNow()
+	}
+
+	// a hold pressed during the delay applies when the next block spawns
+	if tetrisGame.HoldBlock == nil || tetrisGame.HoldBlock.EntityType != entity.I || tetrisGame.CurrentBlock.EntityType != entity.O {
+		t.Error("Hold pressed during the delay should hold the I")
+		t.Fail()
+	}
+}
+
+func TestTSpinWhileFalling(t *testing.T) {
+	tetrisGame := newTestGame([]string{"GGGG......", "GGG...GGGG", "GGGG.GGGGG", "GGGGGGGGG.", "GGGGGGGGG."}, entity.T)
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	// T pointing right just above the slot, turning it lets it fall in on the same tick
+	block, _ := entity.New(entity.T, entity.PIECE_COLORS[entity.T], [2]int{0, 0})
+	block.RotateBlock(entity.CLOCKWISE)
+	center := block.OccupiedPosition[entity.BLOCK_CENTER[entity.T]]
+	block.MoveBlock([2]int{4 - center[0], 15 - center[1]})
+	tetrisGame.CurrentBlock = &block
+	tetrisGame.currentSpeed = 1
+
+	tetrisGame.Update(eventhandler.UpdateEvent{RotateDirection: entity.CLOCKWISE})
+	tetrisGame.Update(eventhandler.UpdateEvent{HardDrop: true})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	if tetrisGame.TSpinDoubles != 1 {
+		t.Errorf("Gravity should not undo the spin, found %d T-spin doubles and\n%s", tetrisGame.TSpinDoubles, strings.Join(tetrisGame.Board().StackRows(), "\n"))
+		t.Fail()
+	}
+}
+
+func TestNextThemeWhilePaused(t *testing.T) {
+	tetrisGame := newTestGame(nil, entity.O)
+	themes, _ := renderer.NewThemes(renderer.THEMES, renderer.DEFAULT_THEME)
+	tetrisGame.Renderer.Themes = themes
+
+	// the game didn't start yet, so it is still paused
+	tetrisGame.Update(eventhandler.UpdateEvent{NextTheme: true})
+
+	if themes.Current != 1 || tetrisGame.State != PAUSE {
+		t.Errorf("The theme should switch without starting the game, found theme %d in state %d", themes.Current, tetrisGame.State)
+		t.Fail()
+	}
+}
@@ -1,37 +1,659 @@
 package main
 
 import (
-	"math/rand"
-	"tetris/collision"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"tetris/audio"
+	"tetris/bot"
+	"tetris/controls"
+	"tetris/editor"
+	"tetris/environment"
+	eventhandler "tetris/event_handler"
+	"tetris/fumen"
 	"tetris/game"
-	"tetris/spawner"
-	treecoordinate "tetris/tree_coordinate"
+	"tetris/mode"
+	mousecontrol "tetris/mouse_control"
+	"tetris/netplay"
+	"tetris/puzzle"
+	"tetris/simulation"
+	"tetris/spectate"
+	"tetris/tbp"
 	renderer "tetris/ui"
+	"tetris/versus"
 	"time"
 )
 
 // TODO: fix bug agane on projection, it should decide based on the distance probably
-// TODO: add sound
 
 func main() {
-	totalBlockHorizontal, totalVertical := 10, 20
+	if len(os.Args) > 1 && os.Args[1] == "env" {
+		runEnvironment(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "tbp" {
+		runBotProtocol(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "sim" {
+		runSimulation(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "versus" {
+		runVersus(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "online" {
+		runOnline(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "puzzle" {
+		runPuzzle(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "edit" {
+		runEditor(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "controls" {
+		runControls(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "audio" {
+		runAudio(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "watch" {
+		runViewer(os.Args[2:])
+		return
+	}
+
+	demo := flag.Bool("demo", false, "let the bot play the game on its own")
+	mouse := flag.Bool("mouse", false, "place the blocks by clicking where they should go, the wheel rotates and the middle button holds")
+	spectateAddress := flag.String("spectate", "", "address to broadcast the game on, e.g. :7000")
+	modeName := flag.String("mode", "", "game mode to play, one of "+strings.Join(mode.Names(), ", ")+", empty opens the menu")
+	scoresPath := flag.String("scores", mode.DefaultHighScorePath(), "file the high scores are kept in")
+	playerName := flag.String("name", "Player", "name the high scores are saved under")
+	controlsPath := flag.String("controls", eventhandler.DefaultBindingsPath(), "file the key and gamepad bindings are kept in")
+	handlingPath := flag.String("handling", eventhandler.DefaultHandlingPath(), "file the auto shift, auto repeat and soft drop settings are kept in")
+	audioPath := flag.String("audio", audio.DefaultSettingsPath(), "file the volume and mute settings are kept in")
+	finesseFault := flag.String("finesse-fault", "flash", "what a finesse fault does in the finesse mode, one of count, flash, restart")
+	themeName := flag.String("theme", renderer.DEFAULT_THEME, "theme to start with, F4 switches to the next one while playing")
+	themesDirectory := flag.String("themes", renderer.DefaultThemesDirectory(), "directory with theme files next to the built in themes")
+	flag.Parse()
+
+	_, handling := useControls(*controlsPath, *handlingPath)
+
+	audioSettings, err := audio.LoadSettings(*audioPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, ok := mode.MODES[*modeName]; *modeName != "" && !ok {
+		log.Fatalf("unknown mode %s", *modeName)
+	}
+
+	if !slices.Contains([]string{"count", "flash", "restart"}, *finesseFault) {
+		log.Fatalf("unknown finesse fault %s", *finesseFault)
+	}
+
+	highScores, err := mode.LoadHighScores(*scoresPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rules := game.RULE_SETS["default"]
 	width := 600
 	height := 800
 	blockXSize, blockYSize := 30, 30
-	speedUpMultiplier := 4
-	coordinateTree := treecoordinate.New()
-	collisionDetector := collision.Collision{MaxWitdh: totalBlockHorizontal, MaxHeight: totalVertical, OccupiedBlocks: coordinateTree}
-	spawnerBlock := spawner.BlockSpawner{MaxWidth: totalBlockHorizontal, Randomizer: *rand.New(rand.NewSource(time.Now().Unix()))}
 	raylibRenderer := renderer.Renderer{
 		Height:               int32(height),
 		Width:                int32(width),
 		BlockXSize:           int32(blockXSize),
 		BlockYSize:           int32(blockYSize),
-		TotalHorizontalBlock: totalBlockHorizontal,
-		TotalVerticalBlock:   totalVertical,
+		TotalHorizontalBlock: rules.MaxWidth,
+		TotalVerticalBlock:   rules.MaxHeight,
+		TargetFps:            60,
+		Themes:               useThemes(*themesDirectory, *themeName),
+	}
+
+	var server *spectate.Server
+	if *spectateAddress != "" {
+		listener, err := net.Listen("tcp", *spectateAddress)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		server = spectate.NewServer()
+		defer server.Close()
+		go server.Serve(listener)
+	}
+
+	raylibRenderer.Init("Tetris")
+	defer raylibRenderer.Close()
+
+	player := audio.NewRaylibPlayer(audioSettings)
+	defer player.Close()
+
+	for {
+		seed := time.Now().Unix()
+		selectedMode, ok := mode.Mode(nil), true
+		if *modeName != "" {
+			selectedMode = mode.MODES[*modeName](seed)
+		} else {
+			selectedMode, ok = mode.Select(raylibRenderer, highScores, seed, *controlsPath)
+		}
+
+		if !ok {
+			return
+		}
+
+		if finesseMode, ok := selectedMode.(mode.Finesse); ok {
+			finesseMode.Flash, finesseMode.Restart = *finesseFault == "flash", *finesseFault == "restart"
+			selectedMode = finesseMode
+		}
+
+		tetrisGame := game.NewFromRules(rules, seed)
+		tetrisGame.Renderer = raylibRenderer
+		tetrisGame.SoftDropFactor = handling.SoftDropFactor
+
+		if *demo {
+			demoBot := bot.New(bot.DEFAULT_WEIGHTS)
+			tetrisGame.EventHandler = bot.Player{Bot: &demoBot, Game: &tetrisGame}
+		} else if *mouse {
+			tetrisGame.EventHandler = &mousecontrol.Controller{Game: &tetrisGame}
+		}
+
+		audio.Listen(tetrisGame.Events, player)
+		tetrisGame.OnUpdate = func(tg *game.TetrisGame) {
+			player.Update()
+			if server != nil {
+				server.Publish(tg)
+			}
+		}
+
+		if !mode.Run(&tetrisGame, selectedMode, highScores, *playerName) {
+			return
+		}
+	}
+}
+
+// Serves the headless game over stdin and stdout for training scripts
+func runEnvironment(args []string) {
+	flags := flag.NewFlagSet("env", flag.ExitOnError)
+	width := flags.Int("width", 10, "max horizontal block index of the board")
+	height := flags.Int("height", 20, "max vertical block index of the board")
+	flags.Parse(args)
+
+	err := environment.New(*width, *height).Serve(os.Stdin, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Lets an external bot speaking the Tetris Bot Protocol play, e.g. tetris tbp -- ./cold-clear
+func runBotProtocol(args []string) {
+	flags := flag.NewFlagSet("tbp", flag.ExitOnError)
+	headless := flags.Bool("headless", false, "play without opening a window")
+	pieces := flags.Int("pieces", 1000, "amount of pieces to play when headless")
+	seed := flags.Int64("seed", time.Now().Unix(), "seed of the block sequence")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		log.Fatal("missing the command to start the bot")
+	}
+
+	frontend, err := tbp.Launch(flags.Arg(0), flags.Args()[1:]...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer frontend.Close()
+
+	// the protocol only knows about boards that are 10 blocks wide
+	tetrisGame := game.NewHeadless(tbp.BOARD_WIDTH-1, 20, *seed)
+	player := tbp.Player{Frontend: frontend, Game: &tetrisGame}
+
+	if *headless {
+		tetrisGame.Start()
+		err = player.Run(*pieces)
+	} else {
+		tetrisGame.Renderer = renderer.Renderer{
+			Height:               800,
+			Width:                600,
+			BlockXSize:           30,
+			BlockYSize:           30,
+			TotalHorizontalBlock: tetrisGame.MaxWitdh,
+			TotalVerticalBlock:   tetrisGame.MaxHeight,
+			TargetFps:            60,
+		}
+		tetrisGame.EventHandler = &player
+		tetrisGame.Play()
+		err = player.Err
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("score %d, lines %d, pieces %d", tetrisGame.Score, tetrisGame.Lines, tetrisGame.Pieces)
+}
+
+// Plays a batch of headless games, mostly to see how rule or performance changes affect the bots
+func runSimulation(args []string) {
+	flags := flag.NewFlagSet("sim", flag.ExitOnError)
+	config := simulation.Config{}
+	flags.IntVar(&config.Games, "games", 100, "amount of games to play")
+	flags.IntVar(&config.Workers, "workers", 0, "amount of games played in parallel, defaults to the amount of cpus")
+	flags.StringVar(&config.Bot, "bot", "heuristic", "bot that plays the games")
+	flags.Int64Var(&config.SeedStart, "seed", 1, "seed of the first game, the others follow it")
+	flags.StringVar(&config.Mode, "mode", "marathon", "game mode")
+	flags.StringVar(&config.Rules, "rules", "default", "rule set")
+	flags.IntVar(&config.MaxPieces, "pieces", 500, "stop a game after this many pieces, 0 plays until it tops out")
+	format := flags.String("format", "table", "output format, table or json")
+	flags.Parse(args)
+
+	report, err := simulation.Run(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *format == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteTable(os.Stdout)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Two players on the same keyboard, WASD on the left board and the arrow keys on the right one
+func runVersus(args []string) {
+	flags := flag.NewFlagSet("versus", flag.ExitOnError)
+	seed := flags.Int64("seed", time.Now().Unix(), "seed of the block sequence, both players get the same one")
+	rulesName := flags.String("rules", "default", "rule set")
+	controlsPaths := []*string{
+		flags.String("controls1", eventhandler.DefaultVersusBindingsPath(1), "file the bindings of the left player are kept in"),
+		flags.String("controls2", eventhandler.DefaultVersusBindingsPath(2), "file the bindings of the right player are kept in"),
+	}
+	handlingPath := flags.String("handling", eventhandler.DefaultHandlingPath(), "file the auto shift, auto repeat and soft drop settings of both players are kept in")
+	flags.Parse(args)
+
+	handling, err := eventhandler.LoadHandling(*handlingPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rules, ok := game.RULE_SETS[*rulesName]
+	if !ok {
+		log.Fatalf("unknown rule set %s", *rulesName)
+	}
+
+	handlers := make([]eventhandler.EventHandler, len(controlsPaths))
+	for i, path := range controlsPaths {
+		bindings, err := eventhandler.LoadBindings(*path, eventhandler.VERSUS_BINDINGS[i])
+		if err != nil {
+			log.Fatal(err)
+		}
+		handlers[i] = eventhandler.NewInputHandler(bindings, handling, int32(i))
+	}
+	match := versus.New(rules, *seed, []string{"Player 1", "Player 2"}, handlers)
+	for _, player := range match.Players {
+		player.Game.SoftDropFactor = handling.SoftDropFactor
+	}
+	match.Renderer = renderer.Renderer{
+		Height:               800,
+		Width:                1000,
+		BlockXSize:           30,
+		BlockYSize:           30,
+		TotalHorizontalBlock: rules.MaxWidth,
+		TotalVerticalBlock:   rules.MaxHeight,
+		TargetFps:            60,
+	}
+
+	match.Play()
+}
+
+// Versus over the network, e.g. tetris online -host :7777 on one machine and tetris online -join 127.0.0.1:7777 on the other
+func runOnline(args []string) {
+	flags := flag.NewFlagSet("online", flag.ExitOnError)
+	hostAddress := flags.String("host", "", "address to wait for the opponent on")
+	joinAddress := flags.String("join", "", "address of the host to join")
+	name := flags.String("name", "Player", "name shown above the board")
+	rulesName := flags.String("rules", "", "rule set to insist on, empty accepts the rules of the other side")
+	seed := flags.Int64("seed", time.Now().Unix(), "seed of the block sequence, only used by the host")
+	inputDelay := flags.Int("delay", netplay.DEFAULT_INPUT_DELAY, "ticks between pressing a key and the game reacting to it")
+	controlsPath := flags.String("controls", eventhandler.DefaultBindingsPath(), "file the key and gamepad bindings are kept in")
+	handlingPath := flags.String("handling", eventhandler.DefaultHandlingPath(), "file the auto shift, auto repeat and soft drop settings are kept in")
+	flags.Parse(args)
+
+	bindings, handling := useControls(*controlsPath, *handlingPath)
+
+	config := netplay.Config{Name: *name, Seed: *seed, InputDelay: *inputDelay, SoftDropFactor: handling.SoftDropFactor}
+	if *rulesName != "" {
+		rules, ok := game.RULE_SETS[*rulesName]
+		if !ok {
+			log.Fatalf("unknown rule set %s", *rulesName)
+		}
+		config.Rules = &rules
+	}
+
+	var session *netplay.Session
+	var err error
+
+	if *hostAddress != "" {
+		listener, listenErr := net.Listen("tcp", *hostAddress)
+		if listenErr != nil {
+			log.Fatal(listenErr)
+		}
+		log.Printf("waiting for an opponent on %s", listener.Addr())
+		session, err = netplay.Host(listener, config)
+		listener.Close()
+	} else if *joinAddress != "" {
+		session, err = netplay.Join(*joinAddress, config)
+	} else {
+		log.Fatal("either -host or -join is needed")
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer session.Close()
+
+	hostGame := session.Match.Players[0].Game
+	session.Match.Renderer = renderer.Renderer{
+		Height:               800,
+		Width:                1000,
+		BlockXSize:           30,
+		BlockYSize:           30,
+		TotalHorizontalBlock: hostGame.MaxWitdh,
+		TotalVerticalBlock:   hostGame.MaxHeight,
+		TargetFps:            60,
+	}
+
+	session.Play(eventhandler.NewInputHandler(bindings, handling, 0))
+}
+
+// Plays a puzzle file until it is solved, e.g. tetris puzzle -file puzzles/tspin_double.json.
+// A fumen is played as a practice board, e.g. tetris puzzle -fumen v115@vhAAgH
+func runPuzzle(args []string) {
+	flags := flag.NewFlagSet("puzzle", flag.ExitOnError)
+	path := flags.String("file", "", "puzzle to play")
+	fumenData := flags.String("fumen", "", "fumen to practice on instead of a puzzle file")
+	replayPath := flags.String("replay", "", "file the last game is written to as a fumen")
+	controlsPath := flags.String("controls", eventhandler.DefaultBindingsPath(), "file the key and gamepad bindings are kept in")
+	handlingPath := flags.String("handling", eventhandler.DefaultHandlingPath(), "file the auto shift, auto repeat and soft drop settings are kept in")
+	themeName := flags.String("theme", renderer.DEFAULT_THEME, "theme to start with, F4 switches to the next one while playing")
+	themesDirectory := flags.String("themes", renderer.DefaultThemesDirectory(), "directory with theme files next to the built in themes")
+	flags.Parse(args)
+
+	_, handling := useControls(*controlsPath, *handlingPath)
+
+	p, err := loadPuzzle(*path, *fumenData)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rules := game.RULE_SETS[p.Rules]
+	raylibRenderer := renderer.Renderer{
+		Height:               800,
+		Width:                600,
+		BlockXSize:           30,
+		BlockYSize:           30,
+		TotalHorizontalBlock: rules.MaxWidth,
+		TotalVerticalBlock:   rules.MaxHeight,
 		TargetFps:            60,
+		Themes:               useThemes(*themesDirectory, *themeName),
+	}
+	raylibRenderer.Init("Tetris")
+	defer raylibRenderer.Close()
+
+	for {
+		tetrisGame, err := p.NewGame(time.Now().Unix())
+		if err != nil {
+			log.Fatal(err)
+		}
+		tetrisGame.Renderer = raylibRenderer
+		tetrisGame.SoftDropFactor = handling.SoftDropFactor
+
+		recorder := fumen.Recorder{}
+		tetrisGame.OnUpdate = recorder.Observe
+
+		solved := puzzle.Run(&tetrisGame, p)
+		if *replayPath != "" {
+			writeReplay(recorder, *replayPath)
+		}
+
+		if !solved {
+			return
+		}
+	}
+}
+
+func loadPuzzle(path, fumenData string) (puzzle.Puzzle, error) {
+	if fumenData == "" {
+		return puzzle.Load(path)
+	}
+
+	pages, err := fumen.Decode(fumenData)
+	if err != nil {
+		return puzzle.Puzzle{}, err
+	}
+
+	return fumen.Puzzle(pages, "Fumen")
+}
+
+func writeReplay(recorder fumen.Recorder, path string) {
+	data, err := recorder.Encode()
+	if err == nil {
+		err = os.WriteFile(path, []byte(data+"\n"), 0644)
+	}
+
+	if err != nil {
+		log.Printf("replay not written: %s", err.Error())
+		return
+	}
+	log.Printf("replay written to %s", path)
+}
+
+// Builds a puzzle or practice board by hand, e.g. tetris edit -file puzzles/mine.json
+func runEditor(args []string) {
+	flags := flag.NewFlagSet("edit", flag.ExitOnError)
+	path := flags.String("file", "board.json", "puzzle to edit, it is created on the first save when it doesn't exist")
+	rulesName := flags.String("rules", puzzle.DEFAULT_RULES, "rule set of a new board")
+	flags.Parse(args)
+
+	boardEditor, err := editor.New(*rulesName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, statErr := os.Stat(*path); statErr == nil {
+		p, err := puzzle.Load(*path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		boardEditor, err = editor.FromPuzzle(p)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	rules := game.RULE_SETS[boardEditor.Rules]
+	raylibRenderer := renderer.Renderer{
+		Height:               800,
+		Width:                600,
+		BlockXSize:           30,
+		BlockYSize:           30,
+		TotalHorizontalBlock: rules.MaxWidth,
+		TotalVerticalBlock:   rules.MaxHeight,
+		TargetFps:            60,
+	}
+	raylibRenderer.Init("Tetris editor")
+	defer raylibRenderer.Close()
+
+	editor.Run(raylibRenderer, &boardEditor, *path)
+}
+
+// Rebinds the keys and gamepad buttons of the game, e.g. tetris controls -file controls.json, or the ones of a
+// versus player with tetris controls -player 2. Handling settings given as flags are saved right away, e.g. tetris controls -das 8 -arr 0
+func runControls(args []string) {
+	flags := flag.NewFlagSet("controls", flag.ExitOnError)
+	path := flags.String("file", "", "file the bindings are saved to, defaults to the one of the player")
+	versusPlayer := flags.Int("player", 0, "versus player to rebind, 1 or 2, 0 rebinds the single player controls")
+	handlingPath := flags.String("handling", eventhandler.DefaultHandlingPath(), "file the handling settings are saved to")
+	delayedAutoShift := flags.Int("das", -1, "ticks a direction has to be held before it repeats")
+	autoRepeatRate := flags.Int("arr", -1, "ticks between repeated moves, 0 moves straight to the wall")
+	softDropFactor := flags.Int("sdf", -1, "how many times faster soft drop is than gravity")
+	flags.Parse(args)
+
+	if *versusPlayer < 0 || *versusPlayer > len(eventhandler.VERSUS_BINDINGS) {
+		log.Fatalf("unknown versus player %d", *versusPlayer)
+	}
+
+	defaults, defaultPath := eventhandler.DEFAULT_BINDINGS, eventhandler.DefaultBindingsPath()
+	if *versusPlayer > 0 {
+		defaults, defaultPath = eventhandler.VERSUS_BINDINGS[*versusPlayer-1], eventhandler.DefaultVersusBindingsPath(*versusPlayer)
+	}
+	if *path == "" {
+		*path = defaultPath
+	}
+
+	bindings, err := eventhandler.LoadBindings(*path, defaults)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	handling, err := eventhandler.LoadHandling(*handlingPath)
+	if err != nil {
+		log.Fatal(err)
 	}
-	tetrisGame := game.New(totalBlockHorizontal, totalVertical, collisionDetector, spawnerBlock, raylibRenderer, speedUpMultiplier, 1)
 
-	tetrisGame.Play()
+	if *delayedAutoShift >= 0 || *autoRepeatRate >= 0 || *softDropFactor >= 0 {
+		if *delayedAutoShift >= 0 {
+			handling.DelayedAutoShift = *delayedAutoShift
+		}
+		if *autoRepeatRate >= 0 {
+			handling.AutoRepeatRate = *autoRepeatRate
+		}
+		if *softDropFactor >= 0 {
+			handling.SoftDropFactor = *softDropFactor
+		}
+
+		if err := handling.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		if err := handling.Save(*handlingPath); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("handling saved to %s", *handlingPath)
+		return
+	}
+
+	screen := controls.New(bindings, defaults)
+	raylibRenderer := renderer.Renderer{Height: 800, Width: 600, TargetFps: 60}
+	raylibRenderer.Init("Tetris controls")
+	defer raylibRenderer.Close()
+
+	controls.Run(raylibRenderer, &screen, *path)
+}
+
+// Games without an event handler of their own play with the bindings and handling of the config files
+func useControls(bindingsPath, handlingPath string) (eventhandler.Bindings, eventhandler.Handling) {
+	bindings, err := eventhandler.LoadBindings(bindingsPath, eventhandler.DEFAULT_BINDINGS)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	handling, err := eventhandler.LoadHandling(handlingPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	eventhandler.UseControls(bindings, handling)
+	return bindings, handling
+}
+
+// Built in themes and the ones of the directory, starting with the named one
+func useThemes(directory, name string) *renderer.Themes {
+	list, err := renderer.LoadThemes(directory)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	themes, err := renderer.NewThemes(list, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return themes
+}
+
+// Changes and saves the volume of the game, e.g. tetris audio -volume 0.8 -music 0.2 or tetris audio -mute
+func runAudio(args []string) {
+	flags := flag.NewFlagSet("audio", flag.ExitOnError)
+	path := flags.String("file", audio.DefaultSettingsPath(), "file the audio settings are saved to")
+	volume := flags.Float64("volume", -1, "volume of the sound effects, from 0 to 1")
+	musicVolume := flags.Float64("music", -1, "volume of the music, from 0 to 1")
+	mute := flags.Bool("mute", false, "play no sound at all, -mute=false turns it back on")
+	flags.Parse(args)
+
+	settings, err := audio.LoadSettings(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *volume >= 0 {
+		settings.Volume = float32(*volume)
+	}
+	if *musicVolume >= 0 {
+		settings.MusicVolume = float32(*musicVolume)
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "mute" {
+			settings.Muted = *mute
+		}
+	})
+
+	if err := settings.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if err := settings.Save(*path); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("audio settings saved to %s", *path)
+}
+
+// Watches a game started with -spectate, e.g. tetris watch -address 192.168.1.20:7000
+func runViewer(args []string) {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	address := flags.String("address", "127.0.0.1:7000", "address the game is broadcast on")
+	flags.Parse(args)
+
+	viewer, err := spectate.Watch(*address)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer viewer.Close()
+
+	viewer.Renderer = renderer.Renderer{
+		Height:     800,
+		Width:      600,
+		BlockXSize: 30,
+		BlockYSize: 30,
+		TargetFps:  60,
+	}
+	viewer.Play()
 }
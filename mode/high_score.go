@@ -0,0 +1,118 @@
+package mode
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"tetris/game"
+	"time"
+)
+
+const (
+	MAX_HIGH_SCORES = 10
+)
+
+type Record struct {
+	Name   string    `json:"name"`
+	Score  int       `json:"score"`
+	Lines  int       `json:"lines"`
+	Pieces int       `json:"pieces"`
+	Ticks  int       `json:"ticks"`
+	Date   time.Time `json:"date"`
+	Stats  *Stats    `json:"stats,omitempty"`
+}
+
+// Best runs of every mode, kept in a JSON file
+type HighScores struct {
+	Path       string              `json:"-"`
+	Categories map[string][]Record `json:"categories"`
+}
+
+func NewRecord(name string, tg *game.TetrisGame) Record {
+	return Record{
+		Name:   name,
+		Score:  tg.Score,
+		Lines:  tg.Lines,
+		Pieces: tg.Pieces,
+		Ticks:  tg.Ticks,
+		Date:   time.Now(),
+	}
+}
+
+// Next to the other settings of the user, or the working directory when there is no such place
+func DefaultHighScorePath() string {
+	configDirectory, err := os.UserConfigDir()
+	if err != nil {
+		return "highscores.json"
+	}
+
+	return filepath.Join(configDirectory, "tetris", "highscores.json")
+}
+
+// A missing file is the same as having no high scores yet
+func LoadHighScores(path string) (*HighScores, error) {
+	highScores := &HighScores{Path: path, Categories: make(map[string][]Record)}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return highScores, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, highScores); err != nil {
+		return nil, err
+	}
+
+	if highScores.Categories == nil {
+		highScores.Categories = make(map[string][]Record)
+	}
+
+	return highScores, nil
+}
+
+func (h *HighScores) Save() error {
+	content, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.Path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(h.Path, content, 0644)
+}
+
+// Inserts the record in the category of the mode, returns its rank starting from 0 or -1 when it didn't make it
+func (h *HighScores) Add(m Mode, record Record) int {
+	records := append(h.Categories[m.Name()], record)
+	sort.SliceStable(records, func(i, j int) bool {
+		return m.Better(records[i], records[j])
+	})
+
+	if len(records) > MAX_HIGH_SCORES {
+		records = records[:MAX_HIGH_SCORES]
+	}
+	h.Categories[m.Name()] = records
+
+	for rank := range records {
+		if records[rank] == record {
+			return rank
+		}
+	}
+
+	return -1
+}
+
+func (h *HighScores) Best(m Mode) (Record, bool) {
+	records := h.Categories[m.Name()]
+	if len(records) == 0 {
+		return Record{}, false
+	}
+
+	return records[0], true
+}
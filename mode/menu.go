@@ -0,0 +1,129 @@
+package mode
+
+import (
+	"fmt"
+	"tetris/controls"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	renderer "tetris/ui"
+)
+
+// Lets the player pick a mode in a window that is already open, next to the best record of every mode.
+// The last entry rebinds the controls kept in controlsPath. Returns false when the window got closed instead.
+func Select(r renderer.Renderer, highScores *HighScores, seed int64, controlsPath string) (Mode, bool) {
+	names := Names()
+	modes := make([]Mode, len(names))
+	options := make([]string, len(names))
+	for i, name := range names {
+		modes[i] = MODES[name](seed)
+		options[i] = name
+		if best, ok := highScores.Best(modes[i]); ok {
+			options[i] = fmt.Sprintf("%s  (best %s)", name, modes[i].Summary(best))
+		}
+	}
+	options = append(options, "controls")
+	selected := 0
+
+	for !r.ShouldClose() {
+		event := eventhandler.HandleMenuEvent()
+		selected = (selected + event.Move + len(names) + 1) % (len(names) + 1)
+
+		if event.Select && selected == len(names) {
+			if !rebind(r, controlsPath) {
+				return nil, false
+			}
+			continue
+		}
+
+		if event.Select {
+			return modes[selected], true
+		}
+
+		r.RenderMenu("Tetris", options, selected)
+	}
+
+	return nil, false
+}
+
+// Opens the controls screen, the games played afterwards use the bindings it went back with
+func rebind(r renderer.Renderer, controlsPath string) bool {
+	bindings, err := eventhandler.LoadBindings(controlsPath, eventhandler.DEFAULT_BINDINGS)
+	if err != nil {
+		bindings = eventhandler.DEFAULT_BINDINGS
+	}
+
+	screen := controls.New(bindings, eventhandler.DEFAULT_BINDINGS)
+	bindings, ok := controls.Run(r, &screen, controlsPath)
+	if ok {
+		eventhandler.UseBindings(bindings)
+	}
+
+	return ok
+}
+
+// Plays the mode in a window that is already open until the game ends and the player confirms the result.
+// Returns false when the window got closed instead.
+func Run(tg *game.TetrisGame, m Mode, highScores *HighScores, playerName string) bool {
+	stats := Track(tg, m)
+	Start(tg, m)
+	tg.Goal = withStats{Mode: m, stats: stats}
+	lines := []string(nil)
+
+	for !tg.Renderer.ShouldClose() {
+		if tg.State != game.LOSE && tg.State != game.FINISHED {
+			tg.Update(tg.ReceiveEvent())
+			if tg.OnUpdate != nil {
+				tg.OnUpdate(tg)
+			}
+			tg.Render()
+			continue
+		}
+
+		if lines == nil {
+			stats.Sync(tg)
+			lines = result(tg, m, stats, highScores, playerName)
+		}
+
+		title := "Finished"
+		if tg.State == game.LOSE {
+			title = "Topped out"
+		}
+		tg.Renderer.RenderFinished(title, lines)
+
+		if eventhandler.HandleMenuEvent().Select {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Records the run and describes it for the result screen
+func result(tg *game.TetrisGame, m Mode, stats *Stats, highScores *HighScores, playerName string) []string {
+	record := NewRecord(playerName, tg)
+	record.Stats = stats
+	lines := append(m.Hud(tg), fmt.Sprintf("%s: %s", m.Name(), m.Summary(record)))
+	lines = append(lines, stats.Describe()...)
+
+	if m.Qualifies(tg) {
+		if rank := highScores.Add(m, record); rank >= 0 {
+			lines = append(lines, fmt.Sprintf("High score #%d", rank+1))
+			if err := highScores.Save(); err != nil {
+				lines = append(lines, fmt.Sprintf("Could not save the high scores: %s", err.Error()))
+			}
+		}
+	}
+
+	return append(lines, "Press enter to go back to the menu")
+}
+
+// The HUD of the mode with the stats of the game below it
+type withStats struct {
+	Mode
+	stats *Stats
+}
+
+func (w withStats) Hud(tg *game.TetrisGame) []string {
+	w.stats.Sync(tg)
+	return append(w.Mode.Hud(tg), w.stats.Hud()...)
+}
@@ -0,0 +1,323 @@
+package mode
+
+import (
+	"fmt"
+	"sort"
+	"tetris/board"
+	"tetris/collision"
+	"tetris/entity"
+	"tetris/finesse"
+	"tetris/game"
+	"time"
+)
+
+const (
+	SPRINT_LINES   = 40
+	ULTRA_TICKS    = 2 * 60 * game.TICKS_PER_SECOND
+	DIG_ROWS       = 10
+	FINESSE_PIECES = 40
+)
+
+// A way to play the game, with its own goal, HUD and high score category
+type Mode interface {
+	game.Goal
+	Name() string
+	// whether a run that ended this way makes it into the high scores
+	Qualifies(tg *game.TetrisGame) bool
+	// whether record a ranks above record b
+	Better(a, b Record) bool
+	// the number the mode ranks by, formatted for the menu and the result screen
+	Summary(record Record) string
+}
+
+// Modes that change the game before it starts, a pre-filled board for example
+type Preparer interface {
+	Prepare(tg *game.TetrisGame)
+}
+
+var MODES map[string]func(seed int64) Mode = map[string]func(seed int64) Mode{
+	"marathon": func(seed int64) Mode {
+		return Marathon{}
+	},
+	"sprint": func(seed int64) Mode {
+		return Sprint{Lines: SPRINT_LINES}
+	},
+	"ultra": func(seed int64) Mode {
+		return Ultra{Ticks: ULTRA_TICKS}
+	},
+	"dig": func(seed int64) Mode {
+		return Dig{Rows: DIG_ROWS, Holes: collision.HOLE_STRATEGIES["messy"](seed)}
+	},
+	"finesse": func(seed int64) Mode {
+		return Finesse{Pieces: FINESSE_PIECES, Flash: true, Trainer: &finesse.Trainer{}}
+	},
+}
+
+// Sets the mode up on a game that didn't start yet and starts it
+func Start(tg *game.TetrisGame, m Mode) {
+	tg.Goal = m
+	if preparer, ok := m.(Preparer); ok {
+		preparer.Prepare(tg)
+	}
+	tg.Start()
+}
+
+// The endless game, ranked by score
+type Marathon struct{}
+
+// Clear the line target as fast as possible
+type Sprint struct {
+	Lines int
+}
+
+// Score as much as possible before the time runs out
+type Ultra struct {
+	Ticks int
+}
+
+// Dig through the garbage the board starts with, ranked by time and then by pieces
+type Dig struct {
+	Rows  int
+	Holes collision.HoleStrategy
+}
+
+// Place the pieces with as few presses as possible, ranked by the finesse faults and then by time
+type Finesse struct {
+	Pieces  int
+	Flash   bool // flashes the screen on a fault
+	Restart bool // a fault takes the piece back, it is played again until it is placed without one
+	Trainer *finesse.Trainer
+}
+
+func (m Marathon) Name() string {
+	return "marathon"
+}
+
+func (m Marathon) Finished(tg *game.TetrisGame) bool {
+	return false
+}
+
+func (m Marathon) Qualifies(tg *game.TetrisGame) bool {
+	return true
+}
+
+func (m Marathon) Better(a, b Record) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.Lines > b.Lines
+}
+
+func (m Marathon) Summary(record Record) string {
+	return fmt.Sprintf("%d", record.Score)
+}
+
+func (m Marathon) Hud(tg *game.TetrisGame) []string {
+	return []string{
+		fmt.Sprintf("Score: %d", tg.Score),
+		fmt.Sprintf("Level: %d", tg.Level),
+		fmt.Sprintf("Lines: %d", tg.Lines),
+		fmt.Sprintf("Time: %s", FormatTicks(tg.Ticks)),
+	}
+}
+
+func (s Sprint) Name() string {
+	return "sprint"
+}
+
+func (s Sprint) Finished(tg *game.TetrisGame) bool {
+	return tg.Lines >= s.Lines
+}
+
+func (s Sprint) Qualifies(tg *game.TetrisGame) bool {
+	return tg.State == game.FINISHED
+}
+
+func (s Sprint) Better(a, b Record) bool {
+	if a.Ticks != b.Ticks {
+		return a.Ticks < b.Ticks
+	}
+	return a.Pieces < b.Pieces
+}
+
+func (s Sprint) Summary(record Record) string {
+	return FormatTicks(record.Ticks)
+}
+
+func (s Sprint) Hud(tg *game.TetrisGame) []string {
+	return []string{
+		fmt.Sprintf("Lines: %d/%d", min(tg.Lines, s.Lines), s.Lines),
+		fmt.Sprintf("Time: %s", FormatTicks(tg.Ticks)),
+		fmt.Sprintf("Pieces: %d", tg.Pieces),
+		fmt.Sprintf("PPS: %.2f", PiecesPerSecond(tg.Pieces, tg.Ticks)),
+	}
+}
+
+func (u Ultra) Name() string {
+	return "ultra"
+}
+
+func (u Ultra) Finished(tg *game.TetrisGame) bool {
+	return tg.Ticks >= u.Ticks
+}
+
+func (u Ultra) Qualifies(tg *game.TetrisGame) bool {
+	return tg.State == game.FINISHED
+}
+
+func (u Ultra) Better(a, b Record) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.Lines > b.Lines
+}
+
+func (u Ultra) Summary(record Record) string {
+	return fmt.Sprintf("%d", record.Score)
+}
+
+func (u Ultra) Hud(tg *game.TetrisGame) []string {
+	return []string{
+		fmt.Sprintf("Time left: %s", FormatTicks(max(0, u.Ticks-tg.Ticks))),
+		fmt.Sprintf("Score: %d", tg.Score),
+		fmt.Sprintf("Lines: %d", tg.Lines),
+	}
+}
+
+func (d Dig) Name() string {
+	return "dig"
+}
+
+func (d Dig) Prepare(tg *game.TetrisGame) {
+	initialBoard := board.New(tg.MaxWitdh+1, tg.MaxHeight+1)
+
+	for i, hole := range d.Holes.Holes(d.placedRows(tg), initialBoard.Width) {
+		y := initialBoard.Height - 1 - i
+		for x := range initialBoard.Width {
+			if x != hole {
+				initialBoard.Set(x, y, entity.GRAY, board.GARBAGE)
+			}
+		}
+	}
+
+	tg.InitialBoard = &initialBoard
+}
+
+// Garbage rows the board starts with, the spawn row always stays free
+func (d Dig) placedRows(tg *game.TetrisGame) int {
+	return min(d.Rows, tg.MaxHeight)
+}
+
+func (d Dig) Finished(tg *game.TetrisGame) bool {
+	return tg.GarbageLines >= d.placedRows(tg)
+}
+
+func (d Dig) Qualifies(tg *game.TetrisGame) bool {
+	return tg.State == game.FINISHED
+}
+
+func (d Dig) Better(a, b Record) bool {
+	if a.Ticks != b.Ticks {
+		return a.Ticks < b.Ticks
+	}
+	return a.Pieces < b.Pieces
+}
+
+func (d Dig) Summary(record Record) string {
+	return fmt.Sprintf("%s, %d pieces", FormatTicks(record.Ticks), record.Pieces)
+}
+
+func (d Dig) Hud(tg *game.TetrisGame) []string {
+	return []string{
+		fmt.Sprintf("Garbage left: %d/%d", max(0, d.placedRows(tg)-tg.GarbageLines), d.placedRows(tg)),
+		fmt.Sprintf("Lines: %d garbage, %d regular", tg.GarbageLines, tg.Lines-tg.GarbageLines),
+		fmt.Sprintf("Time: %s", FormatTicks(tg.Ticks)),
+		fmt.Sprintf("Pieces: %d", tg.Pieces),
+	}
+}
+
+func (f Finesse) Name() string {
+	return "finesse"
+}
+
+func (f Finesse) Prepare(tg *game.TetrisGame) {
+	f.Trainer.Follow(tg)
+	f.Trainer.OnFault = func(fault finesse.Fault) {
+		if f.Flash {
+			tg.Renderer.Flash()
+		}
+		if f.Restart {
+			tg.Retry(fault.Board, fault.Spawned)
+		}
+	}
+}
+
+// Pieces that stayed on the board, the ones taken back for a retry don't count
+func (f Finesse) placed(tg *game.TetrisGame) int {
+	if f.Restart {
+		return tg.Pieces - f.Trainer.Faults
+	}
+
+	return tg.Pieces
+}
+
+func (f Finesse) Finished(tg *game.TetrisGame) bool {
+	return f.placed(tg) >= f.Pieces
+}
+
+func (f Finesse) Qualifies(tg *game.TetrisGame) bool {
+	return tg.State == game.FINISHED && f.placed(tg) >= f.Pieces
+}
+
+func (f Finesse) Better(a, b Record) bool {
+	if a.Stats.FinesseFaults != b.Stats.FinesseFaults {
+		return a.Stats.FinesseFaults < b.Stats.FinesseFaults
+	}
+	return a.Ticks < b.Ticks
+}
+
+func (f Finesse) Summary(record Record) string {
+	return fmt.Sprintf("%d faults, %s", record.Stats.FinesseFaults, FormatTicks(record.Ticks))
+}
+
+func (f Finesse) Hud(tg *game.TetrisGame) []string {
+	lines := []string{
+		fmt.Sprintf("Pieces: %d/%d", min(f.placed(tg), f.Pieces), f.Pieces),
+		fmt.Sprintf("Faults: %d of %d", f.Trainer.Faults, f.Trainer.Judged),
+		fmt.Sprintf("Time: %s", FormatTicks(tg.Ticks)),
+	}
+
+	if f.Trainer.Last != nil {
+		lines = append(lines, fmt.Sprintf("Last fault: %d presses, %s would do", f.Trainer.Last.Presses, finesse.Describe(f.Trainer.Last.Minimal)))
+	}
+
+	return lines
+}
+
+// Game time as minutes, seconds and hundredths, e.g. 01:05.33
+func FormatTicks(ticks int) string {
+	duration := time.Duration(ticks) * time.Second / game.TICKS_PER_SECOND
+	minutes := int(duration.Minutes())
+	seconds := duration.Seconds() - float64(minutes*60)
+
+	return fmt.Sprintf("%02d:%05.2f", minutes, seconds)
+}
+
+func PiecesPerSecond(pieces, ticks int) float64 {
+	if ticks == 0 {
+		return 0
+	}
+
+	return float64(pieces) * game.TICKS_PER_SECOND / float64(ticks)
+}
+
+// Mode names in the order the menu shows them
+func Names() []string {
+	names := make([]string, 0, len(MODES))
+	for name := range MODES {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,321 @@
+package mode
+
+import (
+	"path/filepath"
+	"testing"
+	"tetris/board"
+	"tetris/bot"
+	"tetris/collision"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/finesse"
+	"tetris/game"
+	"tetris/spawner"
+)
+
+// Lets the heuristic bot play the mode headlessly until the game stops
+func playWithBot(m Mode, seed int64, maxTicks int) *game.TetrisGame {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], seed)
+	Start(&tetrisGame, m)
+	heuristicBot := bot.New(bot.DEFAULT_WEIGHTS)
+
+	for tetrisGame.State == game.PLAY && tetrisGame.Ticks < maxTicks {
+		tetrisGame.Update(heuristicBot.NextEvent(&tetrisGame))
+	}
+
+	return &tetrisGame
+}
+
+func TestSprintFinishesOnTheLineTarget(t *testing.T) {
+	tetrisGame := playWithBot(Sprint{Lines: 4}, 1, 100000)
+
+	if tetrisGame.State != game.FINISHED {
+		t.Errorf("Sprint should finish, state is %d", tetrisGame.State)
+		t.FailNow()
+	}
+
+	if tetrisGame.Lines < 4 || !(Sprint{Lines: 4}).Qualifies(tetrisGame) {
+		t.Errorf("Sprint should finish once 4 lines are cleared, found %d lines", tetrisGame.Lines)
+		t.Fail()
+	}
+
+	ticks := tetrisGame.Ticks
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	if tetrisGame.Ticks != ticks {
+		t.Error("Finished game should not keep ticking")
+		t.Fail()
+	}
+}
+
+func TestUltraFinishesOnTheTimeLimit(t *testing.T) {
+	tetrisGame := playWithBot(Ultra{Ticks: 600}, 1, 100000)
+
+	if tetrisGame.State != game.FINISHED || tetrisGame.Ticks != 600 {
+		t.Errorf("Ultra should finish after exactly 600 ticks, found state %d after %d ticks", tetrisGame.State, tetrisGame.Ticks)
+		t.Fail()
+	}
+}
+
+func TestMarathonNeverFinishes(t *testing.T) {
+	tetrisGame := playWithBot(Marathon{}, 1, 3000)
+
+	if tetrisGame.State != game.PLAY {
+		t.Errorf("Marathon should still be running, state is %d", tetrisGame.State)
+		t.Fail()
+	}
+}
+
+func TestHighScoresRankPerMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores", "highscores.json")
+	highScores, err := LoadHighScores(path)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	sprint, ultra := Sprint{Lines: 40}, Ultra{Ticks: ULTRA_TICKS}
+	highScores.Add(sprint, Record{Name: "slow", Ticks: 6000})
+	if rank := highScores.Add(sprint, Record{Name: "fast", Ticks: 3000}); rank != 0 {
+		t.Errorf("Faster sprint should rank first, found rank %d", rank)
+		t.Fail()
+	}
+
+	highScores.Add(ultra, Record{Name: "low", Score: 10})
+	if rank := highScores.Add(ultra, Record{Name: "high", Score: 100}); rank != 0 {
+		t.Errorf("Higher ultra score should rank first, found rank %d", rank)
+		t.Fail()
+	}
+
+	if err := highScores.Save(); err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	loaded, err := LoadHighScores(path)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if best, _ := loaded.Best(sprint); best.Name != "fast" {
+		t.Errorf("Best sprint should be fast, found %s", best.Name)
+		t.Fail()
+	}
+
+	if best, _ := loaded.Best(ultra); best.Name != "high" {
+		t.Errorf("Best ultra should be high, found %s", best.Name)
+		t.Fail()
+	}
+
+	if _, ok := loaded.Best(Marathon{}); ok {
+		t.Error("Marathon should have no records yet")
+		t.Fail()
+	}
+}
+
+func TestHighScoresKeepOnlyTheBest(t *testing.T) {
+	highScores, _ := LoadHighScores(filepath.Join(t.TempDir(), "highscores.json"))
+
+	for score := range MAX_HIGH_SCORES {
+		highScores.Add(Marathon{}, Record{Score: 100 + score})
+	}
+
+	if rank := highScores.Add(Marathon{}, Record{Score: 1}); rank != -1 {
+		t.Errorf("Worst score should not make it in, found rank %d", rank)
+		t.Fail()
+	}
+
+	if len(highScores.Categories["marathon"]) != MAX_HIGH_SCORES {
+		t.Errorf("Only %d records should be kept, found %d", MAX_HIGH_SCORES, len(highScores.Categories["marathon"]))
+		t.Fail()
+	}
+}
+
+func TestFormatTicks(t *testing.T) {
+	if formatted := FormatTicks(65*game.TICKS_PER_SECOND + 30); formatted != "01:05.50" {
+		t.Errorf("Expected 01:05.50, found %s", formatted)
+		t.Fail()
+	}
+}
+
+func TestDigStartsWithGarbage(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	dig := MODES["dig"](1).(Dig)
+	Start(&tetrisGame, dig)
+	currentBoard := tetrisGame.Board()
+
+	for y := currentBoard.Height - dig.Rows; y < currentBoard.Height; y++ {
+		if tetrisGame.CollisionDetector.GetYCount(y) != currentBoard.Width-1 {
+			t.Errorf("Garbage row %d should have a single hole, found %d blocks", y, tetrisGame.CollisionDetector.GetYCount(y))
+			t.Fail()
+		}
+
+		for x := range currentBoard.Width {
+			if currentBoard.Occupied(x, y) && currentBoard.Cells[y][x] != entity.GRAY {
+				t.Errorf("Cell x: %d y: %d should be garbage", x, y)
+				t.Fail()
+			}
+		}
+	}
+
+	if tetrisGame.CollisionDetector.GetYCount(currentBoard.Height-dig.Rows-1) != 0 {
+		t.Error("Rows above the garbage should be empty")
+		t.Fail()
+	}
+}
+
+func TestDigFinishesOnceTheGarbageIsGone(t *testing.T) {
+	dig := Dig{Rows: 2, Holes: collision.HOLE_STRATEGIES["clean"](1)}
+	tetrisGame := playWithBot(dig, 1, 100000)
+
+	if tetrisGame.State != game.FINISHED {
+		t.Errorf("Dig should finish, state is %d", tetrisGame.State)
+		t.FailNow()
+	}
+
+	if tetrisGame.GarbageLines != 2 || tetrisGame.Lines < tetrisGame.GarbageLines {
+		t.Errorf("Both garbage lines should be counted, found %d garbage lines out of %d", tetrisGame.GarbageLines, tetrisGame.Lines)
+		t.Fail()
+	}
+
+	for _, row := range tetrisGame.Board().Cells {
+		for _, value := range row {
+			if value == entity.GRAY {
+				t.Error("No garbage should be left on the board")
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestDigWithMoreRowsThanTheBoard(t *testing.T) {
+	dig := Dig{Rows: 100, Holes: collision.HOLE_STRATEGIES["clean"](1)}
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	Start(&tetrisGame, dig)
+
+	// every row but the spawn row starts with garbage, clearing all of them is all there is to do
+	if rows := len(tetrisGame.Board().StackRows()); rows != tetrisGame.MaxHeight {
+		t.Errorf("Expected %d garbage rows, found %d", tetrisGame.MaxHeight, rows)
+		t.FailNow()
+	}
+
+	tetrisGame.GarbageLines = tetrisGame.MaxHeight
+	if !dig.Finished(&tetrisGame) {
+		t.Error("Dig should finish once the rows that fit on the board are cleared")
+		t.Fail()
+	}
+}
+
+func TestStatsOfASprint(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	stats := Track(&tetrisGame, Sprint{Lines: 4})
+	Start(&tetrisGame, Sprint{Lines: 4})
+	heuristicBot := bot.New(bot.DEFAULT_WEIGHTS)
+
+	for tetrisGame.State == game.PLAY && tetrisGame.Ticks < 100000 {
+		event := heuristicBot.NextEvent(&tetrisGame)
+		event.Presses = 1
+		tetrisGame.Update(event)
+	}
+	stats.Sync(&tetrisGame)
+
+	if stats.Pieces != tetrisGame.Pieces || stats.Lines != tetrisGame.Lines || stats.Keys != tetrisGame.Ticks {
+		t.Errorf("Stats should follow the game, found %d pieces, %d lines and %d keys", stats.Pieces, stats.Lines, stats.Keys)
+		t.Fail()
+	}
+
+	distributed, levelTicks := 0, 0
+	for _, count := range stats.Distribution {
+		distributed += count
+	}
+	for _, ticks := range stats.LevelTicks {
+		levelTicks += ticks
+	}
+	if distributed != stats.Pieces || levelTicks != tetrisGame.Ticks {
+		t.Errorf("Every piece and tick should be counted once, found %d pieces and %d ticks", distributed, levelTicks)
+		t.Fail()
+	}
+
+	highScores, _ := LoadHighScores(filepath.Join(t.TempDir(), "highscores.json"))
+	record := NewRecord("stats", &tetrisGame)
+	record.Stats = stats
+	highScores.Add(Sprint{Lines: 4}, record)
+	highScores.Save()
+
+	loaded, _ := LoadHighScores(highScores.Path)
+	if best, _ := loaded.Best(Sprint{Lines: 4}); best.Stats == nil || best.Stats.Pieces != stats.Pieces {
+		t.Error("Stats should be saved with the high score")
+		t.Fail()
+	}
+}
+
+func TestStatsCountCombos(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	initialBoard, _ := board.ParseRows([]string{"..GGGGGGGG", "..GGGGGGGG", "..GGGGGGGG", "..GGGGGGGG", "GGG.GGGGGG"}, 10, 20)
+	tetrisGame.InitialBoard = &initialBoard
+	tetrisGame.Spawner.Sequence = spawner.NewSequence([]int{entity.O, entity.O, entity.O})
+	stats := Track(&tetrisGame, nil)
+	tetrisGame.Start()
+
+	for tetrisGame.State == game.PLAY && tetrisGame.Ticks < 1000 {
+		tetrisGame.Update(eventhandler.UpdateEvent{})
+		tetrisGame.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.LEFT, ToWall: true, HardDrop: true})
+		tetrisGame.Update(eventhandler.UpdateEvent{})
+	}
+
+	// a double each and one more for the combo
+	if stats.MaxCombo != 1 || stats.Clears["Double"] != 2 || stats.Attack != 3 {
+		t.Errorf("Two doubles in a row should be a combo of 1 sending 3 rows, found combo %d with clears %v and attack %d", stats.MaxCombo, stats.Clears, stats.Attack)
+		t.Fail()
+	}
+}
+
+func TestFinesseRanksByFaults(t *testing.T) {
+	tetrisGame := playWithBot(MODES["finesse"](1), 1, 100000)
+	if tetrisGame.State != game.FINISHED || tetrisGame.Pieces != FINESSE_PIECES {
+		t.Errorf("Finesse should finish after %d pieces, found %d", FINESSE_PIECES, tetrisGame.Pieces)
+		t.Fail()
+	}
+
+	clean := Record{Ticks: 5000, Stats: &Stats{FinesseFaults: 0}}
+	fast := Record{Ticks: 3000, Stats: &Stats{FinesseFaults: 2}}
+	if !(Finesse{}).Better(clean, fast) || (Finesse{}).Better(fast, clean) {
+		t.Error("Fewer faults should rank above a faster time")
+		t.Fail()
+	}
+}
+
+func TestFinesseRestartRetriesThePiece(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	tetrisGame.Spawner.Sequence = spawner.NewSequence([]int{entity.O, entity.I, entity.I})
+	finesseMode := Finesse{Pieces: 2, Restart: true, Trainer: &finesse.Trainer{}}
+	stats := Track(&tetrisGame, finesseMode)
+	Start(&tetrisGame, finesseMode)
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	// tapping over and over takes more presses than the spot needs
+	for range 4 {
+		tetrisGame.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.LEFT, Presses: 1})
+		tetrisGame.Update(eventhandler.UpdateEvent{})
+	}
+	// locks the O, the next update takes it back
+	tetrisGame.Update(eventhandler.UpdateEvent{HardDrop: true, Presses: 1})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	if finesseMode.Trainer.Faults != 1 || len(tetrisGame.Board().StackRows()) != 0 || tetrisGame.CurrentBlock.EntityType != entity.O {
+		t.Errorf("The fault should take the O back and bring it in again, found %d faults and the board %v", finesseMode.Trainer.Faults, tetrisGame.Board().StackRows())
+		t.FailNow()
+	}
+
+	tetrisGame.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.LEFT, ToWall: true, Presses: 1})
+	tetrisGame.Update(eventhandler.UpdateEvent{HardDrop: true, Presses: 1})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	stats.Sync(&tetrisGame)
+	if finesseMode.placed(&tetrisGame) != 1 || stats.FinesseFaults != 1 || tetrisGame.CurrentBlock.EntityType != entity.I {
+		t.Errorf("The O should stay once it took a single DAS, found %d placed with %d faults", finesseMode.placed(&tetrisGame), stats.FinesseFaults)
+		t.Fail()
+	}
+}
@@ -0,0 +1,148 @@
+package mode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"tetris/board"
+	"tetris/finesse"
+	"tetris/game"
+	"tetris/versus"
+)
+
+// How a single game was played, kept with its high score entry
+type Stats struct {
+	Pieces        int            `json:"pieces"`
+	Lines         int            `json:"lines"`
+	Keys          int            `json:"keys"`
+	Attack        int            `json:"attack"` // garbage the clears would have sent in versus
+	Ticks         int            `json:"ticks"`
+	MaxCombo      int            `json:"max_combo"`
+	FinesseFaults int            `json:"finesse_faults"`
+	Clears        map[string]int `json:"clears"`       // by the type of clear, e.g. T-Spin Double
+	Distribution  map[string]int `json:"distribution"` // by the letter of the piece
+	LevelTicks    map[int]int    `json:"level_ticks"`
+	attack        *versus.Attack
+	level         int
+	finesse       *finesse.Trainer
+}
+
+func NewStats() *Stats {
+	return &Stats{Clears: make(map[string]int), Distribution: make(map[string]int), LevelTicks: make(map[int]int), attack: versus.NewAttack(), finesse: &finesse.Trainer{}}
+}
+
+// Follows the events of the game, subscribe before the game starts. The faults are the ones of the trainer of the
+// mode when it has one, m may be nil.
+func Track(tg *game.TetrisGame, m Mode) *Stats {
+	stats := NewStats()
+	if finesseMode, ok := m.(Finesse); ok && finesseMode.Trainer != nil {
+		// the mode follows the game with it once it is prepared
+		stats.finesse = finesseMode.Trainer
+	} else {
+		stats.finesse.Follow(tg)
+	}
+
+	tg.Events.Subscribe(func(event game.Event) {
+		switch event := event.(type) {
+		case game.PieceLocked:
+			stats.Pieces += 1
+			stats.Distribution[string(board.PIECE_LETTERS[event.Block.EntityType])] += 1
+			stats.attack.Lock()
+		case game.LinesCleared:
+			stats.clear(event)
+		case game.LevelUp:
+			stats.Sync(tg)
+			stats.level = event.Level
+		}
+		stats.Sync(tg)
+	})
+
+	return stats
+}
+
+func (s *Stats) clear(event game.LinesCleared) {
+	s.Clears[event.Type()] += 1
+	s.Lines += event.Count
+	s.Attack += s.attack.Clear(event)
+	s.MaxCombo = max(s.MaxCombo, s.attack.Combo)
+}
+
+// Catches up with the counters the game keeps itself
+func (s *Stats) Sync(tg *game.TetrisGame) {
+	s.LevelTicks[s.level] += tg.Ticks - s.Ticks
+	s.Ticks = tg.Ticks
+	s.Keys = tg.Keys
+	s.FinesseFaults = s.finesse.Faults
+}
+
+func (s Stats) KeysPerPiece() float64 {
+	if s.Pieces == 0 {
+		return 0
+	}
+
+	return float64(s.Keys) / float64(s.Pieces)
+}
+
+func (s Stats) AttackPerMinute() float64 {
+	if s.Ticks == 0 {
+		return 0
+	}
+
+	return float64(s.Attack) * 60 * game.TICKS_PER_SECOND / float64(s.Ticks)
+}
+
+// Short version shown next to the board while playing
+func (s Stats) Hud() []string {
+	return []string{
+		fmt.Sprintf("PPS: %.2f  KPP: %.2f", PiecesPerSecond(s.Pieces, s.Ticks), s.KeysPerPiece()),
+		fmt.Sprintf("APM: %.1f  Max combo: %d", s.AttackPerMinute(), s.MaxCombo),
+		fmt.Sprintf("Finesse faults: %d", s.FinesseFaults),
+	}
+}
+
+// Everything, for the result screen
+func (s Stats) Describe() []string {
+	lines := []string{
+		fmt.Sprintf("Pieces: %d  Lines: %d  Keys: %d", s.Pieces, s.Lines, s.Keys),
+	}
+	lines = append(lines, s.Hud()...)
+
+	if clears := describeCounts(s.Clears); clears != "" {
+		lines = append(lines, "Clears: "+clears)
+	}
+	if pieces := describeCounts(s.Distribution); pieces != "" {
+		lines = append(lines, "Pieces: "+pieces)
+	}
+
+	levels := make([]int, 0, len(s.LevelTicks))
+	for level := range s.LevelTicks {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+
+	times := make([]string, len(levels))
+	for i, level := range levels {
+		times[i] = fmt.Sprintf("%d %s", level, FormatTicks(s.LevelTicks[level]))
+	}
+	if len(times) > 0 {
+		lines = append(lines, "Time per level: "+strings.Join(times, ", "))
+	}
+
+	return lines
+}
+
+// e.g. Double 2, Single 5
+func describeCounts(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, counts[name])
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,165 @@
+package mousecontrol
+
+import (
+	"tetris/board"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	"tetris/matrix"
+	movegenerator "tetris/move_generator"
+)
+
+// Placement the pointer aims at: the block turned rotation quarter turns clockwise with its leftmost cell as close
+// to column as it gets. A drop straight down wins over tucks and spins into the same column.
+// Placements are told apart by their shape, an upright I is the same whichever way it got turned.
+// Blocks that can't be turned, the O for example, ignore the rotation.
+func Aim(currentBoard board.Board, block entity.BlockEntity, column, rotation int) (movegenerator.Placement, bool) {
+	placements := movegenerator.Generate(currentBoard, block)
+
+	turnedBlock := entity.BlockEntity{EntityType: block.EntityType, OccupiedPosition: matrix.Copy(block.OccupiedPosition)}
+	for range (rotation%4 + 4) % 4 {
+		turnedBlock.RotateBlock(entity.CLOCKWISE)
+	}
+	wanted := shape(turnedBlock.OccupiedPosition)
+
+	turned := make([]movegenerator.Placement, 0)
+	for _, placement := range placements {
+		if shape(placement.Position) == wanted {
+			turned = append(turned, placement)
+		}
+	}
+	if len(turned) > 0 {
+		placements = turned
+	}
+
+	best, found := movegenerator.Placement{}, false
+	for _, placement := range placements {
+		distance, bestDistance := abs(left(placement)-column), abs(left(best)-column)
+		if !found || distance < bestDistance || distance == bestDistance && len(placement.Path) < len(best.Path) {
+			best, found = placement, true
+		}
+	}
+
+	return best, found
+}
+
+// Cells of a position moved into the top left corner
+func shape(position matrix.Matrix) string {
+	moved := matrix.Copy(position)
+	corner := []int{1000, 1000}
+	for _, location := range moved {
+		corner[0], corner[1] = min(corner[0], location[0]), min(corner[1], location[1])
+	}
+	moved.Minus(corner)
+
+	return movegenerator.PositionKey(moved)
+}
+
+func left(placement movegenerator.Placement) int {
+	column := 1000
+	for _, location := range placement.Position {
+		column = min(column, location[0])
+	}
+
+	return column
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+// Plays with the mouse: hovering over a column shows where the block would go, clicking sends it there.
+// The block follows a path the game accepts, so gravity and the rules stay the same as with the keyboard.
+type Controller struct {
+	Game     *game.TetrisGame
+	Rotation int
+	follower *movegenerator.Follower
+	followed *entity.BlockEntity
+}
+
+func (c *Controller) HandleEvent() eventhandler.UpdateEvent {
+	mouse := eventhandler.HandleMouseEvent()
+	column, _, inside := c.Game.Renderer.CellAt(mouse.X, mouse.Y)
+
+	event := c.Step(mouse, column, inside)
+	// pausing and switching themes stay on the keyboard
+	event.Pause = eventhandler.HandlePauseEvent()
+	event.NextTheme = eventhandler.HandleThemeEvent()
+
+	return event
+}
+
+// Input of the game for one frame, column is the one under the pointer
+func (c *Controller) Step(mouse eventhandler.MouseEvent, column int, inside bool) eventhandler.UpdateEvent {
+	event := c.step(mouse, column, inside)
+	// every click counts towards the keys per piece, also the ones made while a placement is still being followed
+	event.Presses = mouse.Presses()
+
+	return event
+}
+
+func (c *Controller) step(mouse eventhandler.MouseEvent, column int, inside bool) eventhandler.UpdateEvent {
+	block := c.Game.CurrentBlock
+	if block == nil {
+		c.Game.Aim = nil
+		return eventhandler.UpdateEvent{}
+	}
+
+	// every spawn and hold brings in a new block that waits for the next click
+	if c.followed != block {
+		c.follower, c.followed = nil, block
+	}
+
+	if c.follower != nil {
+		return c.follow(*block)
+	}
+
+	if mouse.Hold {
+		return eventhandler.UpdateEvent{Hold: true}
+	}
+
+	c.Rotation = (c.Rotation + mouse.Rotate + 4) % 4
+	if !inside {
+		c.Game.Aim = nil
+		return eventhandler.UpdateEvent{}
+	}
+
+	// the search runs on a snapshot of the blocks the collision detector holds
+	currentBoard := c.Game.Board()
+	placement, ok := Aim(currentBoard, *block, column, c.Rotation)
+	if !ok {
+		c.Game.Aim = nil
+		return eventhandler.UpdateEvent{}
+	}
+	c.Game.Aim = placement.Position
+
+	if mouse.Place {
+		follower, err := movegenerator.NewFollower(currentBoard, *block, placement.Position)
+		if err == nil {
+			c.follower = &follower
+			return c.follow(*block)
+		}
+	}
+
+	return eventhandler.UpdateEvent{}
+}
+
+// Steers towards the clicked placement and drops the block once it is there
+func (c *Controller) follow(block entity.BlockEntity) eventhandler.UpdateEvent {
+	if movegenerator.PositionKey(block.OccupiedPosition) == movegenerator.PositionKey(c.follower.Target) {
+		return eventhandler.UpdateEvent{HardDrop: true}
+	}
+
+	event, err := c.follower.NextEvent(c.Game.Board(), block)
+	if err != nil {
+		// gravity took the block past the point where the target is reachable, it can be aimed again
+		c.follower = nil
+		return eventhandler.UpdateEvent{}
+	}
+
+	return event
+}
@@ -0,0 +1,84 @@
+package mousecontrol
+
+import (
+	"strings"
+	"testing"
+	"tetris/board"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	"tetris/matrix"
+	"tetris/spawner"
+)
+
+func TestAim(t *testing.T) {
+	currentBoard := board.New(10, 20)
+	block, _ := entity.New(entity.I, entity.BLUE, [2]int{3, 0})
+
+	placement, ok := Aim(currentBoard, block, 0, 0)
+	if !ok || left(placement) != 0 || placement.Position[0][1] != 19 {
+		t.Errorf("Flat I should land on the floor of the first column, found %v", placement.Position)
+		t.Fail()
+	}
+
+	placement, ok = Aim(currentBoard, block, 9, 1)
+	if !ok || left(placement) != 9 || shape(placement.Position) != shape(matrix.Matrix{{0, 0}, {0, 1}, {0, 2}, {0, 3}}) {
+		t.Errorf("Upright I should land in the last column, found %v", placement.Position)
+		t.Fail()
+	}
+
+	// there is no column 20, the closest one is taken
+	placement, ok = Aim(currentBoard, block, 20, 0)
+	if !ok || left(placement) != 6 {
+		t.Errorf("Flat I should stay against the right wall, found %v", placement.Position)
+		t.Fail()
+	}
+}
+
+func TestClickToPlace(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	tetrisGame.Spawner.Sequence = spawner.NewSequence([]int{entity.I, entity.I, entity.O})
+	tetrisGame.Start()
+
+	controller := Controller{Game: &tetrisGame}
+	columns := []int{0, 4, 8}
+	for tetrisGame.State == game.PLAY && tetrisGame.Ticks < 2000 {
+		column := 0
+		if tetrisGame.Pieces < len(columns) {
+			column = columns[tetrisGame.Pieces]
+		}
+
+		tetrisGame.Update(controller.Step(eventhandler.MouseEvent{Place: true}, column, true))
+	}
+
+	if tetrisGame.Lines != 1 || tetrisGame.Pieces != 3 {
+		t.Errorf("Clicking the three blocks next to each other should clear a line, found %d lines after %d pieces", tetrisGame.Lines, tetrisGame.Pieces)
+		t.FailNow()
+	}
+
+	expected := "........OO"
+	if rows := strings.Join(tetrisGame.Board().StackRows(), "\n"); rows != expected {
+		t.Errorf("Expected the board\n%s\nfound\n%s", expected, rows)
+		t.Fail()
+	}
+}
+
+func TestClicksCountAsKeys(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	tetrisGame.Spawner.Sequence = spawner.NewSequence([]int{entity.I, entity.I})
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	controller := Controller{Game: &tetrisGame}
+	// one notch to stand the I up and one click to place it, the frames after that only follow the placement
+	tetrisGame.Update(controller.Step(eventhandler.MouseEvent{Rotate: 1}, 0, true))
+	tetrisGame.Update(controller.Step(eventhandler.MouseEvent{Place: true}, 0, true))
+	for tetrisGame.State == game.PLAY && tetrisGame.Pieces < 1 && tetrisGame.Ticks < 2000 {
+		tetrisGame.Update(controller.Step(eventhandler.MouseEvent{}, 0, true))
+	}
+
+	if tetrisGame.Pieces != 1 || tetrisGame.Keys != 2 {
+		t.Errorf("Turning and clicking should count 2 keys for the piece, found %d after %d pieces", tetrisGame.Keys, tetrisGame.Pieces)
+		t.Fail()
+	}
+}
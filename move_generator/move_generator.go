@@ -0,0 +1,253 @@
+package movegenerator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"tetris/board"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/matrix"
+)
+
+const (
+	MOVE_LEFT             = 1
+	MOVE_RIGHT            = 2
+	ROTATE_CLOCKWISE      = 3
+	ROTATE_ANTI_CLOCKWISE = 4
+	SOFT_DROP             = 5
+)
+
+// Order in which the inputs are tried, it decides which path wins when two have the same length
+var INPUTS []int = []int{MOVE_LEFT, MOVE_RIGHT, ROTATE_CLOCKWISE, ROTATE_ANTI_CLOCKWISE, SOFT_DROP}
+
+type Placement struct {
+	Position matrix.Matrix // cells of the block once it locks
+	Rotation int           // amount of clockwise quarter turns from the starting orientation
+	Path     []int         // shortest input sequence from the starting position
+}
+
+type state struct {
+	block    entity.BlockEntity
+	rotation int
+	path     []int
+}
+
+// Applies a single input the same way TetrisGame.Update would, returns false when the game would reject it
+func Apply(currentBoard board.Board, block entity.BlockEntity, input int) (entity.BlockEntity, bool) {
+	movedBlock := entity.BlockEntity{EntityType: block.EntityType, Color: block.Color, OccupiedPosition: matrix.Copy(block.OccupiedPosition)}
+
+	switch input {
+	case MOVE_LEFT:
+		movedBlock.MoveBlock([2]int{-1, 0})
+	case MOVE_RIGHT:
+		movedBlock.MoveBlock([2]int{1, 0})
+	case ROTATE_CLOCKWISE:
+		movedBlock.RotateBlock(entity.CLOCKWISE)
+	case ROTATE_ANTI_CLOCKWISE:
+		movedBlock.RotateBlock(entity.ANTI_CLOCKWISE)
+	case SOFT_DROP:
+		movedBlock.MoveBlock([2]int{0, 1})
+	default:
+		return block, false
+	}
+
+	if !currentBoard.Fits(movedBlock.OccupiedPosition) {
+		return block, false
+	}
+
+	return movedBlock, true
+}
+
+// The game locks a block as soon as it touches the last row
+func locked(currentBoard board.Board, block entity.BlockEntity) bool {
+	for _, location := range block.OccupiedPosition {
+		if location[1] >= currentBoard.Height-1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A block rests when it can't fall any further, it locks there on the next gravity step
+func resting(currentBoard board.Board, block entity.BlockEntity) bool {
+	_, canFall := Apply(currentBoard, block, SOFT_DROP)
+	return !canFall || locked(currentBoard, block)
+}
+
+// Breadth first search over every position and rotation the block can reach, calls visit on each state once.
+// Stops early when visit returns true.
+func search(currentBoard board.Board, block entity.BlockEntity, visit func(state) bool) {
+	if !currentBoard.Fits(block.OccupiedPosition) {
+		return
+	}
+
+	visited := map[string]bool{orderedKey(block.OccupiedPosition): true}
+	queue := []state{{block: block, path: []int{}}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if visit(current) {
+			return
+		}
+
+		if locked(currentBoard, current.block) {
+			continue
+		}
+
+		for _, input := range INPUTS {
+			nextBlock, ok := Apply(currentBoard, current.block, input)
+			if !ok {
+				continue
+			}
+
+			key := orderedKey(nextBlock.OccupiedPosition)
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+
+			rotation := current.rotation
+			if input == ROTATE_CLOCKWISE {
+				rotation = (rotation + 1) % 4
+			} else if input == ROTATE_ANTI_CLOCKWISE {
+				rotation = (rotation + 3) % 4
+			}
+
+			path := make([]int, len(current.path)+1)
+			copy(path, current.path)
+			path[len(current.path)] = input
+
+			queue = append(queue, state{block: nextBlock, rotation: rotation, path: path})
+		}
+	}
+}
+
+// Every final resting placement the block can reach, including tucks and spins, each with its shortest path
+func Generate(currentBoard board.Board, block entity.BlockEntity) []Placement {
+	placements := make([]Placement, 0)
+	found := make(map[string]bool)
+
+	search(currentBoard, block, func(current state) bool {
+		if !resting(currentBoard, current.block) {
+			return false
+		}
+
+		key := PositionKey(current.block.OccupiedPosition)
+		if !found[key] {
+			found[key] = true
+			placements = append(placements, Placement{
+				Position: current.block.OccupiedPosition,
+				Rotation: current.rotation,
+				Path:     current.path,
+			})
+		}
+
+		return false
+	})
+
+	return placements
+}
+
+// Shortest input sequence that brings the block onto the target cells
+func FindPath(currentBoard board.Board, block entity.BlockEntity, target matrix.Matrix) ([]int, error) {
+	targetKey := PositionKey(target)
+	var path []int
+
+	search(currentBoard, block, func(current state) bool {
+		if PositionKey(current.block.OccupiedPosition) == targetKey {
+			path = current.path
+			return true
+		}
+
+		return false
+	})
+
+	if path == nil {
+		return nil, errors.New(fmt.Sprintf("Target position is not reachable for block type %d", block.EntityType))
+	}
+
+	return path, nil
+}
+
+func ToEvent(input int) eventhandler.UpdateEvent {
+	switch input {
+	case MOVE_LEFT:
+		return eventhandler.UpdateEvent{MovingDirection: eventhandler.LEFT}
+	case MOVE_RIGHT:
+		return eventhandler.UpdateEvent{MovingDirection: eventhandler.RIGHT}
+	case ROTATE_CLOCKWISE:
+		return eventhandler.UpdateEvent{RotateDirection: entity.CLOCKWISE}
+	case ROTATE_ANTI_CLOCKWISE:
+		return eventhandler.UpdateEvent{RotateDirection: entity.ANTI_CLOCKWISE}
+	case SOFT_DROP:
+		return eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN}
+	}
+
+	return eventhandler.UpdateEvent{}
+}
+
+// Identifies the cells taken by a block regardless of their order
+func PositionKey(position matrix.Matrix) string {
+	locations := make([]string, len(position))
+
+	for i, location := range position {
+		locations[i] = fmt.Sprintf("%d,%d", location[0], location[1])
+	}
+	sort.Strings(locations)
+
+	return fmt.Sprint(locations)
+}
+
+// The order matters while searching, the rotation center is picked by index
+func orderedKey(position matrix.Matrix) string {
+	return fmt.Sprint(position)
+}
+
+// Steers a block towards a target placement one frame at a time. Gravity keeps running while it does,
+// so the path is searched again whenever the block ends up somewhere the plan didn't expect.
+type Follower struct {
+	Target       matrix.Matrix
+	plan         []int
+	planPosition matrix.Matrix // where the block should be before the first input of the plan
+}
+
+func NewFollower(currentBoard board.Board, block entity.BlockEntity, target matrix.Matrix) (Follower, error) {
+	path, err := FindPath(currentBoard, block, target)
+	if err != nil {
+		return Follower{}, err
+	}
+
+	return Follower{Target: target, plan: path, planPosition: matrix.Copy(block.OccupiedPosition)}, nil
+}
+
+// Input for the current frame, fails once the target can't be reached anymore
+func (f *Follower) NextEvent(currentBoard board.Board, block entity.BlockEntity) (eventhandler.UpdateEvent, error) {
+	if len(f.plan) > 0 && !block.OccupiedPosition.Equal(f.planPosition) {
+		plannedBlock := entity.BlockEntity{EntityType: block.EntityType, OccupiedPosition: f.planPosition}
+		nextBlock, _ := Apply(currentBoard, plannedBlock, f.plan[0])
+
+		if block.OccupiedPosition.Equal(nextBlock.OccupiedPosition) {
+			f.plan = f.plan[1:]
+			f.planPosition = nextBlock.OccupiedPosition
+		} else {
+			path, err := FindPath(currentBoard, block, f.Target)
+			if err != nil {
+				return eventhandler.UpdateEvent{}, err
+			}
+
+			f.plan = path
+			f.planPosition = matrix.Copy(block.OccupiedPosition)
+		}
+	}
+
+	// soft drop until it locks, the gravity needs a few frames for every row
+	if len(f.plan) == 0 {
+		return eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN}, nil
+	}
+
+	return ToEvent(f.plan[0]), nil
+}
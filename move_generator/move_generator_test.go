@@ -0,0 +1,84 @@
+package movegenerator
+
+import (
+	"testing"
+	"tetris/board"
+	"tetris/entity"
+	"tetris/matrix"
+)
+
+func TestGenerateOnEmptyBoard(t *testing.T) {
+	emptyBoard := board.New(10, 20)
+	block, _ := entity.New(entity.I, entity.RED, [2]int{3, 0})
+
+	placements := Generate(emptyBoard, block)
+
+	// 7 horizontal positions and 10 vertical ones
+	if len(placements) != 17 {
+		t.Errorf("I block should have 17 placements on an empty board, found %d instead", len(placements))
+		t.Fail()
+	}
+}
+
+func TestGenerateShouldFindTuck(t *testing.T) {
+	currentBoard := board.New(6, 6)
+	// overhang on the left side, the only way below it is to slide in after landing on the stack.
+	// touching the last row locks the block right away, hence the filled bottom row
+	currentBoard.Place(matrix.Matrix{{0, 5}, {1, 5}, {2, 5}, {3, 5}, {4, 5}}, entity.RED)
+	currentBoard.Place(matrix.Matrix{{0, 2}, {1, 2}, {2, 2}}, entity.RED)
+	block, _ := entity.New(entity.O, entity.RED, [2]int{3, 0})
+	tuckedPosition := matrix.Matrix{{0, 3}, {1, 3}, {0, 4}, {1, 4}}
+
+	path, err := FindPath(currentBoard, block, tuckedPosition)
+
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	movedBlock := block
+	for _, input := range path {
+		var ok bool
+		movedBlock, ok = Apply(currentBoard, movedBlock, input)
+		if !ok {
+			t.Errorf("Input %d of the path is rejected", input)
+			t.FailNow()
+		}
+	}
+
+	if PositionKey(movedBlock.OccupiedPosition) != PositionKey(tuckedPosition) {
+		t.Errorf("Following the path should end on the tucked position, found %s instead", movedBlock.OccupiedPosition.ToString())
+		t.Fail()
+	}
+}
+
+func TestFindPathShouldBeShortest(t *testing.T) {
+	emptyBoard := board.New(10, 20)
+	block, _ := entity.New(entity.O, entity.RED, [2]int{4, 0})
+	target := matrix.Matrix{{1, 18}, {2, 18}, {1, 19}, {2, 19}}
+
+	path, err := FindPath(emptyBoard, block, target)
+
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	// 3 moves to the left and 18 rows down
+	if len(path) != 21 {
+		t.Errorf("Path should take 21 inputs, found %d instead", len(path))
+		t.Fail()
+	}
+}
+
+func TestLockedBlockShouldNotMoveFurther(t *testing.T) {
+	emptyBoard := board.New(6, 6)
+	block, _ := entity.New(entity.I, entity.RED, [2]int{0, 5})
+
+	placements := Generate(emptyBoard, block)
+
+	if len(placements) != 1 {
+		t.Errorf("Block on the last row is already locked, found %d placements", len(placements))
+		t.Fail()
+	}
+}
@@ -0,0 +1,188 @@
+package netplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	"time"
+)
+
+const (
+	PROTOCOL_VERSION = 1
+	// inputs are scheduled this many ticks ahead so the round trip doesn't stall every frame
+	DEFAULT_INPUT_DELAY = 3
+	DISCONNECT_TIMEOUT  = 5 * time.Second
+)
+
+const (
+	MESSAGE_HELLO  = "hello"
+	MESSAGE_START  = "start"
+	MESSAGE_REJECT = "reject"
+	MESSAGE_INPUT  = "input"
+	MESSAGE_BYE    = "bye"
+)
+
+// Everything both sides send each other, one JSON object per line
+type Message struct {
+	Type           string                    `json:"type"`
+	Version        int                       `json:"version,omitempty"`
+	Name           string                    `json:"name,omitempty"`
+	Rules          *game.Rules               `json:"rules,omitempty"`
+	Seed           int64                     `json:"seed,omitempty"`
+	InputDelay     int                       `json:"input_delay,omitempty"`
+	Reason         string                    `json:"reason,omitempty"`
+	Tick           int                       `json:"tick"`
+	Event          *eventhandler.UpdateEvent `json:"event,omitempty"`
+	Hash           uint64                    `json:"hash,omitempty"`             // state after Tick-InputDelay-1, checked by the other side
+	Garbage        int                       `json:"garbage"`                    // rows the sender sent on that same tick
+	SoftDropFactor int                       `json:"soft_drop_factor,omitempty"` // handling of the sender, both sides play its game with it
+}
+
+// Rules left empty accept whatever the other side proposes
+type Config struct {
+	Name           string
+	Rules          *game.Rules
+	Seed           int64
+	InputDelay     int
+	SoftDropFactor int // of the local player, 0 keeps the default one
+}
+
+var ErrDisconnected error = errors.New("Opponent disconnected")
+
+type DesyncError struct {
+	Tick int
+}
+
+func (e DesyncError) Error() string {
+	return fmt.Sprintf("Games went out of sync on tick %d", e.Tick)
+}
+
+type connection struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	scanner *bufio.Scanner
+}
+
+func newConnection(conn net.Conn) *connection {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	return &connection{conn: conn, encoder: json.NewEncoder(conn), scanner: scanner}
+}
+
+func (c *connection) send(message Message) error {
+	c.conn.SetWriteDeadline(time.Now().Add(DISCONNECT_TIMEOUT))
+	if err := c.encoder.Encode(message); err != nil {
+		return ErrDisconnected
+	}
+
+	return nil
+}
+
+// A timeout is treated the same as a closed connection, the match can't go on without the other inputs
+func (c *connection) receive() (Message, error) {
+	c.conn.SetReadDeadline(time.Now().Add(DISCONNECT_TIMEOUT))
+	if !c.scanner.Scan() {
+		return Message{}, ErrDisconnected
+	}
+
+	var message Message
+	if err := json.Unmarshal(c.scanner.Bytes(), &message); err != nil {
+		return Message{}, err
+	}
+
+	if message.Type == MESSAGE_BYE {
+		return message, ErrDisconnected
+	}
+
+	return message, nil
+}
+
+// Waits for a single opponent on the listener, the host decides the seed and plays on the left board
+func Host(listener net.Listener, config Config) (*Session, error) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	connection := newConnection(conn)
+	hello, err := connection.receive()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reason := ""
+	rules := config.Rules
+	if hello.Type != MESSAGE_HELLO {
+		reason = fmt.Sprintf("Expecting %s message, found %s instead", MESSAGE_HELLO, hello.Type)
+	} else if hello.Version != PROTOCOL_VERSION {
+		reason = fmt.Sprintf("Protocol version %d is not supported, host speaks version %d", hello.Version, PROTOCOL_VERSION)
+	} else if rules != nil && hello.Rules != nil && *rules != *hello.Rules {
+		reason = fmt.Sprintf("Host plays with rules %+v, joining player asked for %+v", *rules, *hello.Rules)
+	}
+
+	if reason != "" {
+		connection.send(Message{Type: MESSAGE_REJECT, Reason: reason})
+		conn.Close()
+		return nil, errors.New(reason)
+	}
+
+	if rules == nil {
+		rules = hello.Rules
+	}
+	if rules == nil {
+		defaultRules := game.RULE_SETS["default"]
+		rules = &defaultRules
+	}
+
+	inputDelay := max(config.InputDelay, hello.InputDelay, 1)
+	start := Message{Type: MESSAGE_START, Version: PROTOCOL_VERSION, Name: config.Name, Rules: rules, Seed: config.Seed, InputDelay: inputDelay, SoftDropFactor: config.SoftDropFactor}
+	if err := connection.send(start); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	session := newSession(connection, 0, *rules, config.Seed, inputDelay, []string{config.Name, hello.Name})
+	session.useSoftDropFactors(config.SoftDropFactor, hello.SoftDropFactor)
+	return session, nil
+}
+
+// Connects to a host and plays with whatever rules and seed it picked, unless the config insists on other rules
+func Join(address string, config Config) (*Session, error) {
+	conn, err := net.DialTimeout("tcp", address, DISCONNECT_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := newConnection(conn)
+	hello := Message{Type: MESSAGE_HELLO, Version: PROTOCOL_VERSION, Name: config.Name, Rules: config.Rules, InputDelay: config.InputDelay, SoftDropFactor: config.SoftDropFactor}
+	if err := connection.send(hello); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	start, err := connection.receive()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if start.Type == MESSAGE_REJECT {
+		conn.Close()
+		return nil, errors.New(fmt.Sprintf("Host refused with reason %s", start.Reason))
+	}
+
+	if start.Type != MESSAGE_START || start.Rules == nil {
+		conn.Close()
+		return nil, errors.New(fmt.Sprintf("Expecting %s message, found %s instead", MESSAGE_START, start.Type))
+	}
+
+	session := newSession(connection, 1, *start.Rules, start.Seed, start.InputDelay, []string{start.Name, config.Name})
+	session.useSoftDropFactors(start.SoftDropFactor, config.SoftDropFactor)
+	return session, nil
+}
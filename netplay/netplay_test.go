@@ -0,0 +1,190 @@
+package netplay
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"tetris/bot"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+)
+
+// Hosts on a random localhost port and joins it from the same process
+func connect(t *testing.T, hostConfig, joinConfig Config) (*Session, *Session, error, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+	defer listener.Close()
+
+	hosted := make(chan *Session)
+	var hostErr error
+	go func() {
+		session, err := Host(listener, hostConfig)
+		hostErr = err
+		hosted <- session
+	}()
+
+	joined, joinErr := Join(listener.Addr().String(), joinConfig)
+	host := <-hosted
+
+	return host, joined, hostErr, joinErr
+}
+
+// Plays the given amount of ticks with a bot controlling the local board, closes the session on errors
+func play(session *Session, ticks int, beforeStep func(tick int)) error {
+	localBot := bot.New(bot.DEFAULT_WEIGHTS)
+
+	for tick := range ticks {
+		beforeStep(tick)
+		if err := session.Step(localBot.NextEvent(session.Match.Players[session.LocalIndex].Game)); err != nil {
+			session.Close()
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestHandshakeNegotiatesRules(t *testing.T) {
+	standard := game.RULE_SETS["standard"]
+	host, joined, hostErr, joinErr := connect(t, Config{Name: "host", Seed: 42, InputDelay: 2, SoftDropFactor: 20}, Config{Name: "guest", Rules: &standard, InputDelay: 4})
+	if hostErr != nil || joinErr != nil {
+		t.Errorf("Handshake should succeed, host error: %v, join error: %v", hostErr, joinErr)
+		t.FailNow()
+	}
+	defer host.Close()
+	defer joined.Close()
+
+	for _, session := range []*Session{host, joined} {
+		if session.Match.Players[0].Game.MaxWitdh != standard.MaxWidth || session.Match.Players[0].Game.MaxHeight != standard.MaxHeight {
+			t.Error("Host without rules should take the rules of the joining player")
+			t.Fail()
+		}
+
+		if session.InputDelay != 4 {
+			t.Errorf("Both sides should use the bigger input delay, found %d", session.InputDelay)
+			t.Fail()
+		}
+
+		if session.Match.Players[0].Name != "host" || session.Match.Players[1].Name != "guest" {
+			t.Error("Host should play on the left board")
+			t.Fail()
+		}
+
+		if session.Match.Players[0].Game.SoftDropFactor != 20 || session.Match.Players[1].Game.SoftDropFactor != game.DEFAULT_SOFT_DROP_FACTOR {
+			t.Error("Every game should soft drop with the factor of its player, or the default one when it has none")
+			t.Fail()
+		}
+	}
+
+	if host.LocalIndex != 0 || joined.LocalIndex != 1 {
+		t.Error("Host and guest should control different boards")
+		t.Fail()
+	}
+}
+
+func TestHandshakeRejectsDifferentRules(t *testing.T) {
+	standard, defaultRules := game.RULE_SETS["standard"], game.RULE_SETS["default"]
+	_, _, hostErr, joinErr := connect(t, Config{Name: "host", Rules: &defaultRules}, Config{Name: "guest", Rules: &standard})
+
+	if hostErr == nil || joinErr == nil {
+		t.Error("Both sides should fail when they insist on different rules")
+		t.Fail()
+	}
+}
+
+func TestLockstepStaysInSync(t *testing.T) {
+	standard := game.RULE_SETS["standard"]
+	host, joined, hostErr, joinErr := connect(t, Config{Name: "host", Rules: &standard, Seed: 7}, Config{Name: "guest"})
+	if hostErr != nil || joinErr != nil {
+		t.Errorf("Handshake should succeed, host error: %v, join error: %v", hostErr, joinErr)
+		t.FailNow()
+	}
+	defer host.Close()
+	defer joined.Close()
+
+	ticks := 1200
+	joinDone := make(chan error)
+	go func() {
+		joinDone <- play(joined, ticks, func(int) {})
+	}()
+
+	if err := play(host, ticks, func(int) {}); err != nil {
+		t.Errorf("Host should not fail, found %s", err.Error())
+		t.Fail()
+	}
+
+	if err := <-joinDone; err != nil {
+		t.Errorf("Guest should not fail, found %s", err.Error())
+		t.Fail()
+	}
+
+	if Hash(host.Match) != Hash(joined.Match) {
+		t.Error("Both sides should end up with the same match")
+		t.Fail()
+	}
+
+	if host.Match.Players[0].Game.Pieces == 0 || host.Match.Players[1].Game.Pieces == 0 {
+		t.Error("Both players should have placed blocks")
+		t.Fail()
+	}
+}
+
+func TestDesyncIsDetected(t *testing.T) {
+	host, joined, hostErr, joinErr := connect(t, Config{Name: "host", Seed: 7}, Config{Name: "guest"})
+	if hostErr != nil || joinErr != nil {
+		t.Errorf("Handshake should succeed, host error: %v, join error: %v", hostErr, joinErr)
+		t.FailNow()
+	}
+
+	joinDone := make(chan error)
+	go func() {
+		joinDone <- play(joined, 500, func(int) {})
+	}()
+
+	err := play(host, 500, func(tick int) {
+		if tick == 100 {
+			host.Match.Players[1].Game.Score += 1
+		}
+	})
+
+	var desync DesyncError
+	if !errors.As(err, &desync) {
+		t.Errorf("Host should notice the desync, found %v", err)
+		t.Fail()
+	} else if desync.Tick < 99 || desync.Tick > 100+host.InputDelay+1 {
+		t.Errorf("Desync should be noticed right after tick 100, found tick %d", desync.Tick)
+		t.Fail()
+	}
+
+	if err := <-joinDone; err == nil {
+		t.Error("Guest should stop as well")
+		t.Fail()
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	host, joined, hostErr, joinErr := connect(t, Config{Name: "host", Seed: 7}, Config{Name: "guest"})
+	if hostErr != nil || joinErr != nil {
+		t.Errorf("Handshake should succeed, host error: %v, join error: %v", hostErr, joinErr)
+		t.FailNow()
+	}
+
+	go func() {
+		play(joined, 50, func(int) {})
+		joined.Close()
+	}()
+
+	err := play(host, 500, func(int) {})
+	if !errors.Is(err, ErrDisconnected) {
+		t.Errorf("Host should notice the guest leaving, found %v", err)
+		t.Fail()
+	}
+
+	if host.Step(eventhandler.UpdateEvent{}) != err {
+		t.Error("Session should keep reporting the disconnect")
+		t.Fail()
+	}
+}
@@ -0,0 +1,176 @@
+package netplay
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	"tetris/versus"
+)
+
+type checkpoint struct {
+	hash    uint64
+	garbage []int
+}
+
+// Both sides run the whole match and only trade inputs. Inputs are played InputDelay ticks after they are read,
+// by then the input of the other side has usually arrived already.
+type Session struct {
+	Match       *versus.Match
+	LocalIndex  int
+	InputDelay  int
+	Err         error
+	connection  *connection
+	tick        int
+	localInputs map[int]eventhandler.UpdateEvent
+	checkpoints map[int]checkpoint
+}
+
+func newSession(connection *connection, localIndex int, rules game.Rules, seed int64, inputDelay int, names []string) *Session {
+	return &Session{
+		Match:       versus.New(rules, seed, names, make([]eventhandler.EventHandler, len(names))),
+		LocalIndex:  localIndex,
+		InputDelay:  inputDelay,
+		connection:  connection,
+		localInputs: make(map[int]eventhandler.UpdateEvent),
+		checkpoints: make(map[int]checkpoint),
+	}
+}
+
+// Soft drop factors of the host and the joining player, a side that didn't send one plays with the default one
+func (s *Session) useSoftDropFactors(factors ...int) {
+	for i, factor := range factors {
+		if factor > 0 {
+			s.Match.Players[i].Game.SoftDropFactor = factor
+		}
+	}
+}
+
+func (s *Session) remoteIndex() int {
+	return 1 - s.LocalIndex
+}
+
+// Sends the local input and advances the match by one tick once the input of the other side for that tick is known
+func (s *Session) Step(local eventhandler.UpdateEvent) error {
+	if s.Err != nil || s.Match.Winner != versus.NO_WINNER {
+		return s.Err
+	}
+
+	// pausing only one of the games would never be undone
+	local.GameState = 0
+	local.Pause = false
+	// the other side picks its own theme
+	local.NextTheme = false
+	s.localInputs[s.tick+s.InputDelay] = local
+
+	input := Message{Type: MESSAGE_INPUT, Tick: s.tick + s.InputDelay, Event: &local}
+	if previous, ok := s.checkpoints[s.tick-1]; ok {
+		input.Hash = previous.hash
+		input.Garbage = previous.garbage[s.LocalIndex]
+	}
+
+	if s.Err = s.connection.send(input); s.Err != nil {
+		return s.Err
+	}
+
+	events := make([]eventhandler.UpdateEvent, len(s.Match.Players))
+	events[s.LocalIndex] = s.localInputs[s.tick]
+	delete(s.localInputs, s.tick)
+
+	// the first ticks were scheduled before anyone pressed anything
+	if s.tick >= s.InputDelay {
+		remote, err := s.receiveInput()
+		if err != nil {
+			s.Err = err
+			return err
+		}
+		events[s.remoteIndex()] = *remote.Event
+	}
+
+	s.Match.Update(events)
+
+	garbage := make([]int, len(s.Match.Players))
+	for i, player := range s.Match.Players {
+		garbage[i] = player.SentGarbage
+	}
+	s.checkpoints[s.tick] = checkpoint{hash: Hash(s.Match), garbage: garbage}
+	delete(s.checkpoints, s.tick-s.InputDelay-2)
+	s.tick += 1
+
+	return nil
+}
+
+// Input of the other side for the current tick, along with its view of the match a few ticks ago
+func (s *Session) receiveInput() (Message, error) {
+	remote, err := s.connection.receive()
+	if err != nil {
+		return remote, err
+	}
+
+	if remote.Type != MESSAGE_INPUT || remote.Event == nil || remote.Tick != s.tick {
+		return remote, errors.New(fmt.Sprintf("Expecting input for tick %d, found %s for tick %d", s.tick, remote.Type, remote.Tick))
+	}
+
+	checkedTick := s.tick - s.InputDelay - 1
+	if checkedTick < 0 {
+		return remote, nil
+	}
+
+	local, ok := s.checkpoints[checkedTick]
+	if !ok || local.hash != remote.Hash || local.garbage[s.remoteIndex()] != remote.Garbage {
+		return remote, DesyncError{Tick: checkedTick}
+	}
+
+	return remote, nil
+}
+
+func (s *Session) Close() error {
+	s.connection.send(Message{Type: MESSAGE_BYE})
+	return s.connection.conn.Close()
+}
+
+func (s *Session) Play(eventHandler eventhandler.EventHandler) {
+	s.Match.Init("Tetris Online")
+	defer s.Match.Renderer.Close()
+
+	for !s.Match.Renderer.ShouldClose() {
+		s.Step(eventHandler.HandleEvent())
+
+		if s.Err != nil {
+			names, scores := s.Match.Results()
+			s.Match.Renderer.RenderVersusResult(s.Err.Error(), names, scores)
+		} else {
+			s.Match.Render()
+		}
+	}
+}
+
+// Fingerprint of everything the simulation decides, two sides with the same hash are still in sync
+func Hash(match *versus.Match) uint64 {
+	hash := fnv.New64a()
+	write := func(values ...int) {
+		for _, value := range values {
+			binary.Write(hash, binary.LittleEndian, int64(value))
+		}
+	}
+
+	for _, player := range match.Players {
+		tg := player.Game
+		write(tg.State, tg.BlockState, tg.Score, tg.Lines, tg.Pieces, tg.Ticks, player.PendingGarbage)
+
+		for _, row := range tg.Board().Cells {
+			write(row...)
+		}
+
+		if tg.CurrentBlock != nil {
+			write(tg.CurrentBlock.EntityType)
+			for _, location := range tg.CurrentBlock.OccupiedPosition {
+				write(location...)
+			}
+		}
+	}
+
+	return hash.Sum64()
+}
@@ -0,0 +1,248 @@
+package puzzle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"tetris/board"
+	"tetris/entity"
+	"tetris/game"
+	"tetris/spawner"
+)
+
+const (
+	GOAL_LINES         = "lines"
+	GOAL_PERFECT_CLEAR = "perfect_clear"
+	GOAL_TSPIN_DOUBLE  = "tspin_double"
+	GOAL_SURVIVE       = "survive"
+	GOAL_PRACTICE      = "practice"
+)
+
+const (
+	DEFAULT_RULES = "standard"
+)
+
+var PIECES map[string]int = map[string]int{
+	"I": entity.I,
+	"J": entity.J,
+	"L": entity.L,
+	"O": entity.O,
+	"S": entity.S,
+	"T": entity.T,
+	"Z": entity.Z,
+}
+
+var GOAL_DESCRIPTIONS map[string]string = map[string]string{
+	GOAL_LINES:         "Clear %d lines",
+	GOAL_PERFECT_CLEAR: "Get %d perfect clears",
+	GOAL_TSPIN_DOUBLE:  "Do %d T-spin doubles",
+	GOAL_SURVIVE:       "Place %d pieces without topping out",
+	GOAL_PRACTICE:      "Practice, random pieces follow",
+}
+
+// Order the editor cycles through the goals in
+var GOALS []string = []string{GOAL_LINES, GOAL_PERFECT_CLEAR, GOAL_TSPIN_DOUBLE, GOAL_SURVIVE, GOAL_PRACTICE}
+
+type Goal struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// A fixed situation to solve. The board rows are in the text form of the board package and sit on its floor.
+// The pieces come in the order of the sequence, a practice board has no goal and goes on with random pieces after it.
+type Puzzle struct {
+	Name      string   `json:"name"`
+	Rules     string   `json:"rules"`
+	Board     []string `json:"board"`
+	Sequence  []string `json:"sequence"`
+	Hold      bool     `json:"hold"`
+	HoldPiece string   `json:"hold_piece,omitempty"`
+	Goal      Goal     `json:"goal"`
+}
+
+func (g Goal) Description() string {
+	if g.Type == GOAL_PRACTICE {
+		return GOAL_DESCRIPTIONS[g.Type]
+	}
+
+	return fmt.Sprintf(GOAL_DESCRIPTIONS[g.Type], g.Count)
+}
+
+type Result struct {
+	Success bool
+	Reason  string
+}
+
+func Load(path string) (Puzzle, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Puzzle{}, err
+	}
+
+	return Parse(content)
+}
+
+func Parse(content []byte) (Puzzle, error) {
+	p := Puzzle{Rules: DEFAULT_RULES}
+	if err := json.Unmarshal(content, &p); err != nil {
+		return Puzzle{}, err
+	}
+
+	// a goal without a count means doing it once
+	if p.Goal.Count == 0 {
+		p.Goal.Count = 1
+	}
+
+	return p, p.Validate()
+}
+
+func (p Puzzle) Save(path string) error {
+	content, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
+
+func (p Puzzle) Validate() error {
+	rules, ok := game.RULE_SETS[p.Rules]
+	if !ok {
+		return errors.New(fmt.Sprintf("Unknown rule set %s", p.Rules))
+	}
+
+	if _, ok := GOAL_DESCRIPTIONS[p.Goal.Type]; !ok {
+		return errors.New(fmt.Sprintf("Unknown goal %s", p.Goal.Type))
+	}
+
+	if p.Goal.Count <= 0 {
+		return errors.New(fmt.Sprintf("Goal %s needs a count above 0", p.Goal.Type))
+	}
+
+	if len(p.Sequence) == 0 && p.Goal.Type != GOAL_PRACTICE {
+		return errors.New("Puzzle needs at least one piece")
+	}
+
+	for _, piece := range p.Sequence {
+		if _, ok := PIECES[strings.ToUpper(piece)]; !ok {
+			return errors.New(fmt.Sprintf("Unknown piece %s", piece))
+		}
+	}
+
+	if _, ok := PIECES[strings.ToUpper(p.HoldPiece)]; p.HoldPiece != "" && !ok {
+		return errors.New(fmt.Sprintf("Unknown hold piece %s", p.HoldPiece))
+	}
+
+	if p.Goal.Type == GOAL_SURVIVE && p.Goal.Count > len(p.Sequence) {
+		return errors.New(fmt.Sprintf("Can't survive %d pieces with a sequence of %d", p.Goal.Count, len(p.Sequence)))
+	}
+
+	// the top row is where the blocks spawn
+	_, err := board.ParseRows(p.Board, rules.MaxWidth+1, rules.MaxHeight)
+	return err
+}
+
+// Headless game set up with the board, the sequence and the goal of the puzzle, ready to be started
+func (p Puzzle) NewGame(seed int64) (game.TetrisGame, error) {
+	rules := game.RULE_SETS[p.Rules]
+	tetrisGame := game.NewFromRules(rules, seed)
+
+	initialBoard, err := p.InitialBoard()
+	if err != nil {
+		return game.TetrisGame{}, err
+	}
+
+	blocks := make([]int, len(p.Sequence))
+	for i, piece := range p.Sequence {
+		blocks[i] = PIECES[strings.ToUpper(piece)]
+	}
+
+	tetrisGame.InitialBoard = &initialBoard
+	tetrisGame.Spawner.Sequence = spawner.NewSequence(blocks)
+	tetrisGame.Spawner.Sequence.Endless = p.Goal.Type == GOAL_PRACTICE
+	tetrisGame.Spawner.FixedColumn = true
+	tetrisGame.HoldDisabled = !p.Hold
+	tetrisGame.Goal = p
+
+	if p.HoldPiece != "" {
+		holdPiece := PIECES[strings.ToUpper(p.HoldPiece)]
+		holdBlock, err := entity.New(holdPiece, entity.PIECE_COLORS[holdPiece], [2]int{0, 0})
+		if err != nil {
+			return game.TetrisGame{}, err
+		}
+		tetrisGame.HoldBlock = &holdBlock
+	}
+
+	return tetrisGame, nil
+}
+
+// Board of the size the rules ask for with the rows of the puzzle at the bottom
+func (p Puzzle) InitialBoard() (board.Board, error) {
+	rules, ok := game.RULE_SETS[p.Rules]
+	if !ok {
+		return board.Board{}, errors.New(fmt.Sprintf("Unknown rule set %s", p.Rules))
+	}
+
+	return board.ParseRows(p.Board, rules.MaxWidth+1, rules.MaxHeight+1)
+}
+
+func (p Puzzle) Reached(tg *game.TetrisGame) bool {
+	switch p.Goal.Type {
+	case GOAL_LINES:
+		return tg.Lines >= p.Goal.Count
+	case GOAL_PERFECT_CLEAR:
+		return tg.PerfectClears >= p.Goal.Count
+	case GOAL_TSPIN_DOUBLE:
+		return tg.TSpinDoubles >= p.Goal.Count
+	case GOAL_SURVIVE:
+		return tg.Pieces >= p.Goal.Count
+	}
+
+	// practice has no goal, it goes on until the player tops out
+
+	return false
+}
+
+func (p Puzzle) Finished(tg *game.TetrisGame) bool {
+	return p.Reached(tg)
+}
+
+func (p Puzzle) Hud(tg *game.TetrisGame) []string {
+	lines := []string{p.Name, p.Goal.Description()}
+
+	if p.Goal.Type == GOAL_PRACTICE {
+		lines = append(lines, fmt.Sprintf("Lines: %d", tg.Lines), fmt.Sprintf("Pieces: %d", tg.Pieces))
+	} else {
+		lines = append(lines, fmt.Sprintf("Pieces left: %d", max(0, len(p.Sequence)-tg.Pieces)))
+	}
+
+	if !p.Hold {
+		lines = append(lines, "Hold is disabled")
+	}
+
+	return lines
+}
+
+// Whether the puzzle got solved, only meaningful once the game stopped
+func (p Puzzle) Evaluate(tg *game.TetrisGame) Result {
+	if p.Reached(tg) {
+		return Result{Success: true, Reason: "Goal reached"}
+	}
+
+	if tg.State == game.LOSE {
+		return Result{Reason: "Topped out"}
+	}
+
+	if tg.State == game.FINISHED {
+		return Result{Reason: "Ran out of pieces"}
+	}
+
+	return Result{Reason: "Still playing"}
+}
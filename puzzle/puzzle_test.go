@@ -0,0 +1,248 @@
+package puzzle
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"tetris/bot"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	"tetris/matrix"
+	movegenerator "tetris/move_generator"
+)
+
+func TestExamplePuzzlesLoad(t *testing.T) {
+	paths, _ := filepath.Glob("../puzzles/*.json")
+	if len(paths) == 0 {
+		t.Error("Expected example puzzles")
+		t.FailNow()
+	}
+
+	for _, path := range paths {
+		if _, err := Load(path); err != nil {
+			t.Errorf("%s: %s", path, err.Error())
+			t.Fail()
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := []string{
+		`{"sequence": ["T"], "goal": {"type": "win"}}`,
+		`{"sequence": ["T", "X"], "goal": {"type": "lines"}}`,
+		`{"sequence": [], "goal": {"type": "lines"}}`,
+		`{"sequence": ["T"], "goal": {"type": "survive", "count": 2}}`,
+		`{"sequence": ["T"], "board": ["XXX"], "goal": {"type": "lines"}}`,
+		`{"rules": "tiny", "sequence": ["T"], "goal": {"type": "lines"}}`,
+	}
+
+	for _, content := range invalid {
+		if _, err := Parse([]byte(content)); err == nil {
+			t.Errorf("Puzzle %s should be rejected", content)
+			t.Fail()
+		}
+	}
+}
+
+func newGame(t *testing.T, p Puzzle, seed int64) game.TetrisGame {
+	tetrisGame, err := p.NewGame(seed)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	return tetrisGame
+}
+
+// Steers the pieces onto the given cells one after the other and soft drops the rest
+func playPlacements(tetrisGame *game.TetrisGame, targets []matrix.Matrix) {
+	var follower *movegenerator.Follower
+	var followed *entity.BlockEntity
+
+	for tetrisGame.State == game.PLAY && tetrisGame.Ticks < 10000 {
+		if tetrisGame.CurrentBlock == nil || tetrisGame.Pieces >= len(targets) {
+			tetrisGame.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN})
+			continue
+		}
+
+		// every spawn and hold brings in a new block
+		if followed != tetrisGame.CurrentBlock {
+			newFollower, err := movegenerator.NewFollower(tetrisGame.Board(), *tetrisGame.CurrentBlock, targets[tetrisGame.Pieces])
+			if err != nil {
+				panic(err.Error())
+			}
+			follower, followed = &newFollower, tetrisGame.CurrentBlock
+		}
+
+		event, err := follower.NextEvent(tetrisGame.Board(), *tetrisGame.CurrentBlock)
+		if err != nil {
+			panic(err.Error())
+		}
+		tetrisGame.Update(event)
+	}
+}
+
+func TestTSpinDouble(t *testing.T) {
+	p, err := Load("../puzzles/tspin_double.json")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	tetrisGame := newGame(t, p, 1)
+	tetrisGame.Start()
+	playPlacements(&tetrisGame, []matrix.Matrix{{{4, 17}, {4, 16}, {5, 16}, {3, 16}}})
+
+	if result := p.Evaluate(&tetrisGame); !result.Success {
+		t.Errorf("T-spin double should solve the puzzle, found %s with %d lines and %d T-spins", result.Reason, tetrisGame.Lines, tetrisGame.TSpins)
+		t.Fail()
+	}
+
+	expected := "GGGG......\nGGGGGGGGG.\nGGGGGGGGG."
+	if rows := strings.Join(tetrisGame.Board().StackRows(), "\n"); rows != expected {
+		t.Errorf("Expected the board\n%s\nfound\n%s", expected, rows)
+		t.Fail()
+	}
+}
+
+func TestDroppedTIsNoTSpin(t *testing.T) {
+	p := Puzzle{
+		Rules:    DEFAULT_RULES,
+		Board:    []string{"GGG...GGGG", "GGGG.GGGGG", "GGGGGGGGG."},
+		Sequence: []string{"T"},
+		Goal:     Goal{Type: GOAL_TSPIN_DOUBLE, Count: 1},
+	}
+
+	tetrisGame := newGame(t, p, 1)
+	tetrisGame.Start()
+	playPlacements(&tetrisGame, []matrix.Matrix{{{4, 18}, {4, 17}, {5, 17}, {3, 17}}})
+
+	if tetrisGame.Lines != 2 {
+		t.Errorf("Dropped T should clear 2 lines, found %d", tetrisGame.Lines)
+		t.FailNow()
+	}
+
+	if result := p.Evaluate(&tetrisGame); result.Success || result.Reason != "Ran out of pieces" || tetrisGame.TSpins != 0 {
+		t.Errorf("Dropping the T in should not count as a T-spin, found %v", result)
+		t.Fail()
+	}
+}
+
+func TestTetrisWithHold(t *testing.T) {
+	p, err := Load("../puzzles/tetris.json")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	tetrisGame := newGame(t, p, 1)
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{Hold: true})
+
+	if tetrisGame.CurrentBlock.EntityType != entity.I || tetrisGame.HoldBlock.EntityType != entity.O {
+		t.Error("Holding the O should bring in the I")
+		t.FailNow()
+	}
+
+	playPlacements(&tetrisGame, []matrix.Matrix{{{9, 16}, {9, 17}, {9, 18}, {9, 19}}})
+
+	if result := p.Evaluate(&tetrisGame); !result.Success || tetrisGame.Lines != 4 {
+		t.Errorf("Clearing the four rows at once should solve the puzzle, found %s", result.Reason)
+		t.Fail()
+	}
+}
+
+func TestPiecesSpawnOnTheSameColumn(t *testing.T) {
+	p := Puzzle{Rules: DEFAULT_RULES, Sequence: []string{"T"}, Goal: Goal{Type: GOAL_LINES, Count: 1}}
+
+	for _, seed := range []int64{1, 2, 3} {
+		tetrisGame := newGame(t, p, seed)
+		tetrisGame.Start()
+		tetrisGame.Update(eventhandler.UpdateEvent{})
+
+		if x := tetrisGame.CurrentBlock.OccupiedPosition[0][0]; x != 4 {
+			t.Errorf("The T should spawn in the middle whatever the seed, found it on column %d with seed %d", x, seed)
+			t.Fail()
+		}
+	}
+}
+
+func TestHoldDisabled(t *testing.T) {
+	p := Puzzle{Rules: DEFAULT_RULES, Sequence: []string{"O", "I"}, Goal: Goal{Type: GOAL_LINES, Count: 1}}
+
+	tetrisGame := newGame(t, p, 1)
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+	tetrisGame.Update(eventhandler.UpdateEvent{Hold: true})
+
+	if tetrisGame.HoldBlock != nil || tetrisGame.CurrentBlock.EntityType != entity.O {
+		t.Error("Hold should do nothing when the puzzle disables it")
+		t.Fail()
+	}
+}
+
+func TestTopOutFails(t *testing.T) {
+	p := Puzzle{Rules: DEFAULT_RULES, Sequence: make([]string, 100), Goal: Goal{Type: GOAL_SURVIVE, Count: 100}}
+	for i := range p.Sequence {
+		p.Sequence[i] = "O"
+	}
+
+	tetrisGame := newGame(t, p, 1)
+	tetrisGame.Start()
+	for tetrisGame.State == game.PLAY {
+		tetrisGame.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN})
+	}
+
+	if result := p.Evaluate(&tetrisGame); result.Success || result.Reason != "Topped out" {
+		t.Errorf("Stacking O blocks in one place should top out, found %v", result)
+		t.Fail()
+	}
+}
+
+func TestBotSolvesLines(t *testing.T) {
+	p, err := Load("../puzzles/lines.json")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	tetrisGame := newGame(t, p, 1)
+	tetrisGame.Start()
+	heuristicBot := bot.New(bot.DEFAULT_WEIGHTS)
+	for tetrisGame.State == game.PLAY {
+		tetrisGame.Update(heuristicBot.NextEvent(&tetrisGame))
+	}
+
+	if result := p.Evaluate(&tetrisGame); !result.Success {
+		t.Errorf("Bot should clear 2 lines with the pieces it gets, found %s after %d lines", result.Reason, tetrisGame.Lines)
+		t.Fail()
+	}
+}
+
+func TestPracticeGoesOnWithRandomPieces(t *testing.T) {
+	p := Puzzle{Rules: DEFAULT_RULES, Sequence: []string{"I"}, HoldPiece: "T", Hold: true, Goal: Goal{Type: GOAL_PRACTICE, Count: 1}}
+	if err := p.Validate(); err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	tetrisGame := newGame(t, p, 1)
+	tetrisGame.Start()
+	tetrisGame.Update(eventhandler.UpdateEvent{})
+
+	if tetrisGame.CurrentBlock.EntityType != entity.I || tetrisGame.HoldBlock.EntityType != entity.T {
+		t.Error("Practice should start with the I and hold the T")
+		t.FailNow()
+	}
+
+	for tetrisGame.State == game.PLAY && tetrisGame.Pieces < 5 {
+		tetrisGame.Update(eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN})
+	}
+
+	if tetrisGame.Pieces < 5 {
+		t.Errorf("Practice should keep spawning pieces, stopped after %d", tetrisGame.Pieces)
+		t.Fail()
+	}
+}
@@ -0,0 +1,37 @@
+package puzzle
+
+import (
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+)
+
+// Plays a game built by Puzzle.NewGame in a window that is already open until it is solved or failed and the player confirms the result.
+// Returns false when the window got closed instead.
+func Run(tg *game.TetrisGame, p Puzzle) bool {
+	tg.Start()
+
+	for !tg.Renderer.ShouldClose() {
+		if tg.State != game.LOSE && tg.State != game.FINISHED {
+			tg.Update(tg.ReceiveEvent())
+			if tg.OnUpdate != nil {
+				tg.OnUpdate(tg)
+			}
+			tg.Render()
+			continue
+		}
+
+		result := p.Evaluate(tg)
+		title := "Failed"
+		if result.Success {
+			title = "Solved"
+		}
+
+		tg.Renderer.RenderFinished(title, append(p.Hud(tg), result.Reason, "Press enter to continue"))
+
+		if eventhandler.HandleMenuEvent().Select {
+			return true
+		}
+	}
+
+	return false
+}
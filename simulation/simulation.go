@@ -0,0 +1,249 @@
+package simulation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"tetris/bot"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	"tetris/mode"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// a bot that can't lock a block in this many ticks is stuck, the game counts as topped out
+	MAX_TICKS_PER_PIECE = 5000
+)
+
+type Controller interface {
+	NextEvent(tg *game.TetrisGame) eventhandler.UpdateEvent
+}
+
+var BOTS map[string]func(seed int64) Controller = map[string]func(seed int64) Controller{
+	"heuristic": func(seed int64) Controller {
+		heuristicBot := bot.New(bot.DEFAULT_WEIGHTS)
+		return &heuristicBot
+	},
+	"random": func(seed int64) Controller {
+		randomBot := bot.NewRandom(seed)
+		return &randomBot
+	},
+}
+
+var MODES []string = mode.Names()
+
+type Config struct {
+	Games     int    `json:"games"`
+	Workers   int    `json:"workers"`
+	Bot       string `json:"bot"`
+	SeedStart int64  `json:"seed_start"`
+	Mode      string `json:"mode"`
+	Rules     string `json:"rules"`
+	MaxPieces int    `json:"max_pieces"`
+}
+
+type Result struct {
+	Seed      int64 `json:"seed"`
+	Score     int   `json:"score"`
+	Lines     int   `json:"lines"`
+	Pieces    int   `json:"pieces"`
+	Ticks     int   `json:"ticks"`
+	ToppedOut bool  `json:"topped_out"`
+	Finished  bool  `json:"finished"`
+}
+
+type Report struct {
+	Config              Config        `json:"config"`
+	MeanScore           float64       `json:"mean_score"`
+	MeanLines           float64       `json:"mean_lines"`
+	MinLines            int           `json:"min_lines"`
+	MaxLines            int           `json:"max_lines"`
+	MeanPieces          float64       `json:"mean_pieces"`
+	MeanTicks           float64       `json:"mean_ticks"`
+	TopOutRate          float64       `json:"top_out_rate"`
+	FinishRate          float64       `json:"finish_rate"`
+	PiecesPerSecond     float64       `json:"pieces_per_second"`
+	TicksPerSecond      float64       `json:"ticks_per_second"`
+	Duration            time.Duration `json:"duration_ns"`
+	Allocations         uint64        `json:"allocations"`
+	AllocatedBytes      uint64        `json:"allocated_bytes"`
+	AllocationsPerPiece float64       `json:"allocations_per_piece"`
+	Results             []Result      `json:"results"`
+}
+
+func (c Config) Validate() error {
+	if c.Games <= 0 {
+		return errors.New("Amount of games should be at least 1")
+	}
+
+	if _, ok := BOTS[c.Bot]; !ok {
+		return errors.New(fmt.Sprintf("Unknown bot %s, available bots are %s", c.Bot, strings.Join(names(BOTS), ", ")))
+	}
+
+	if _, ok := game.RULE_SETS[c.Rules]; !ok {
+		return errors.New(fmt.Sprintf("Unknown rule set %s, available rule sets are %s", c.Rules, strings.Join(names(game.RULE_SETS), ", ")))
+	}
+
+	for _, mode := range MODES {
+		if mode == c.Mode {
+			return nil
+		}
+	}
+
+	return errors.New(fmt.Sprintf("Unknown mode %s, available modes are %s", c.Mode, strings.Join(MODES, ", ")))
+}
+
+// Plays every seed of the range on its own goroutine pool and aggregates the results
+func Run(config Config) (Report, error) {
+	if err := config.Validate(); err != nil {
+		return Report{}, err
+	}
+
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+
+	seeds := make(chan int64)
+	results := make([]Result, config.Games)
+	var waitGroup sync.WaitGroup
+
+	var memoryBefore, memoryAfter runtime.MemStats
+	runtime.ReadMemStats(&memoryBefore)
+	startTime := time.Now()
+
+	for range config.Workers {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for seed := range seeds {
+				results[seed-config.SeedStart] = Play(config, seed)
+			}
+		}()
+	}
+
+	for i := range config.Games {
+		seeds <- config.SeedStart + int64(i)
+	}
+	close(seeds)
+	waitGroup.Wait()
+
+	duration := time.Since(startTime)
+	runtime.ReadMemStats(&memoryAfter)
+
+	report := Report{
+		Config:         config,
+		Duration:       duration,
+		Allocations:    memoryAfter.Mallocs - memoryBefore.Mallocs,
+		AllocatedBytes: memoryAfter.TotalAlloc - memoryBefore.TotalAlloc,
+		MinLines:       results[0].Lines,
+		Results:        results,
+	}
+
+	totalPieces, totalTicks, toppedOut, finished := 0, 0, 0, 0
+	for _, result := range results {
+		report.MeanScore += float64(result.Score)
+		report.MeanLines += float64(result.Lines)
+		report.MinLines = min(report.MinLines, result.Lines)
+		report.MaxLines = max(report.MaxLines, result.Lines)
+		totalPieces += result.Pieces
+		totalTicks += result.Ticks
+		if result.ToppedOut {
+			toppedOut += 1
+		}
+		if result.Finished {
+			finished += 1
+		}
+	}
+
+	games := float64(config.Games)
+	report.MeanScore /= games
+	report.MeanLines /= games
+	report.MeanPieces = float64(totalPieces) / games
+	report.MeanTicks = float64(totalTicks) / games
+	report.TopOutRate = float64(toppedOut) / games
+	report.FinishRate = float64(finished) / games
+	report.PiecesPerSecond = float64(totalPieces) / duration.Seconds()
+	report.TicksPerSecond = float64(totalTicks) / duration.Seconds()
+	if totalPieces > 0 {
+		report.AllocationsPerPiece = float64(report.Allocations) / float64(totalPieces)
+	}
+
+	return report, nil
+}
+
+// Plays a single headless game until it tops out, reaches the goal of the mode or the piece limit
+func Play(config Config, seed int64) Result {
+	tetrisGame := game.NewFromRules(game.RULE_SETS[config.Rules], seed)
+	mode.Start(&tetrisGame, mode.MODES[config.Mode](seed))
+	controller := BOTS[config.Bot](seed)
+
+	lastPieces, lastLockTick := 0, 0
+	stuck := false
+
+	for tetrisGame.State == game.PLAY && (config.MaxPieces <= 0 || tetrisGame.Pieces < config.MaxPieces) {
+		tetrisGame.Update(controller.NextEvent(&tetrisGame))
+
+		if tetrisGame.Pieces != lastPieces {
+			lastPieces, lastLockTick = tetrisGame.Pieces, tetrisGame.Ticks
+		} else if tetrisGame.Ticks-lastLockTick > MAX_TICKS_PER_PIECE {
+			stuck = true
+			break
+		}
+	}
+
+	return Result{
+		Seed:      seed,
+		Score:     tetrisGame.Score,
+		Lines:     tetrisGame.Lines,
+		Pieces:    tetrisGame.Pieces,
+		Ticks:     tetrisGame.Ticks,
+		ToppedOut: tetrisGame.State == game.LOSE || stuck,
+		Finished:  tetrisGame.State == game.FINISHED,
+	}
+}
+
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r Report) WriteTable(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(table, "games\t%d\n", r.Config.Games)
+	fmt.Fprintf(table, "bot\t%s\n", r.Config.Bot)
+	fmt.Fprintf(table, "mode\t%s\n", r.Config.Mode)
+	fmt.Fprintf(table, "rules\t%s\n", r.Config.Rules)
+	fmt.Fprintf(table, "seeds\t%d - %d\n", r.Config.SeedStart, r.Config.SeedStart+int64(r.Config.Games)-1)
+	fmt.Fprintf(table, "mean score\t%.1f\n", r.MeanScore)
+	fmt.Fprintf(table, "mean lines\t%.1f (min %d, max %d)\n", r.MeanLines, r.MinLines, r.MaxLines)
+	fmt.Fprintf(table, "mean pieces\t%.1f\n", r.MeanPieces)
+	fmt.Fprintf(table, "mean time\t%s\n", mode.FormatTicks(int(r.MeanTicks)))
+	fmt.Fprintf(table, "top out rate\t%.1f%%\n", r.TopOutRate*100)
+	fmt.Fprintf(table, "finish rate\t%.1f%%\n", r.FinishRate*100)
+	fmt.Fprintf(table, "pieces/second\t%.1f\n", r.PiecesPerSecond)
+	fmt.Fprintf(table, "ticks/second\t%.1f\n", r.TicksPerSecond)
+	fmt.Fprintf(table, "allocations\t%d (%.1f per piece)\n", r.Allocations, r.AllocationsPerPiece)
+	fmt.Fprintf(table, "allocated bytes\t%d\n", r.AllocatedBytes)
+	fmt.Fprintf(table, "duration\t%s\n", r.Duration.Round(time.Millisecond))
+
+	return table.Flush()
+}
+
+func names[T any](options map[string]T) []string {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
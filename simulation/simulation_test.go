@@ -0,0 +1,79 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestRunShouldAggregateEveryGame(t *testing.T) {
+	config := Config{Games: 4, Workers: 2, Bot: "random", SeedStart: 10, Mode: "marathon", Rules: "standard", MaxPieces: 30}
+
+	report, err := Run(config)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if len(report.Results) != 4 {
+		t.Errorf("Report should have 4 results, found %d", len(report.Results))
+		t.FailNow()
+	}
+
+	for i, result := range report.Results {
+		if result.Seed != config.SeedStart+int64(i) {
+			t.Errorf("Result %d should be for seed %d, found %d", i, config.SeedStart+int64(i), result.Seed)
+			t.Fail()
+		}
+
+		if result.Pieces > config.MaxPieces {
+			t.Errorf("Game should stop at %d pieces, found %d", config.MaxPieces, result.Pieces)
+			t.Fail()
+		}
+	}
+
+	if report.PiecesPerSecond <= 0 {
+		t.Error("Pieces per second should be measured")
+		t.Fail()
+	}
+}
+
+func TestPlayShouldBeDeterministic(t *testing.T) {
+	config := Config{Games: 1, Bot: "heuristic", Mode: "marathon", Rules: "standard", MaxPieces: 20}
+
+	first, second := Play(config, 3), Play(config, 3)
+
+	if first != second {
+		t.Errorf("Same seed should play out the same way, found %v and %v", first, second)
+		t.Fail()
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalidConfigs := []Config{
+		{Games: 0, Bot: "heuristic", Mode: "marathon", Rules: "default"},
+		{Games: 1, Bot: "unknown", Mode: "marathon", Rules: "default"},
+		{Games: 1, Bot: "heuristic", Mode: "unknown", Rules: "default"},
+		{Games: 1, Bot: "heuristic", Mode: "marathon", Rules: "unknown"},
+	}
+
+	for _, config := range invalidConfigs {
+		if config.Validate() == nil {
+			t.Errorf("Config %v should be invalid", config)
+			t.Fail()
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	report := Report{Config: Config{Games: 1}, MeanLines: 3}
+	var out bytes.Buffer
+
+	report.WriteJSON(&out)
+
+	var decoded Report
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || decoded.MeanLines != 3 {
+		t.Errorf("Report should survive a round trip through JSON, found %s", out.String())
+		t.Fail()
+	}
+}
@@ -1,19 +1,53 @@
 package spawner
 
 import (
+	"errors"
 	"math/rand"
 	"tetris/entity"
 )
 
+var ErrSequenceEnd error = errors.New("Block sequence ran out")
+
+// When a sequence is set the blocks come from it in order instead of at random, until it runs out
 type BlockSpawner struct {
-	MaxWidth   int
-	Randomizer rand.Rand
+	MaxWidth    int
+	Randomizer  rand.Rand
+	Sequence    *Sequence
+	FixedColumn bool // blocks spawn centered instead of on a random column, e.g. so a puzzle starts the same way every time
+}
+
+// Fixed order of blocks, an endless one goes on with random blocks once it ran out
+type Sequence struct {
+	Blocks   []int
+	Endless  bool
+	position int
+}
+
+func NewSequence(blocks []int) *Sequence {
+	return &Sequence{Blocks: blocks}
 }
 
 func (bs BlockSpawner) Spawn() (entity.BlockEntity, error) {
-	randomBlock := bs.Randomizer.Intn(entity.Z)
+	randomBlock := bs.Randomizer.Intn(entity.Z + 1)
+
+	if bs.Sequence != nil {
+		if bs.Sequence.position < len(bs.Sequence.Blocks) {
+			randomBlock = bs.Sequence.Blocks[bs.Sequence.position]
+			bs.Sequence.position += 1
+		} else if !bs.Sequence.Endless {
+			return entity.BlockEntity{}, ErrSequenceEnd
+		}
+	}
+
+	return bs.SpawnBlock(randomBlock)
+}
+
+// Places the given block type on a random column of the top row, in the color of its type
+func (bs BlockSpawner) SpawnBlock(randomBlock int) (entity.BlockEntity, error) {
 	randomXCoordinate := bs.Randomizer.Intn(bs.MaxWidth)
-	randomColor := bs.Randomizer.Intn(entity.GREEN)
+	if bs.FixedColumn {
+		randomXCoordinate = centerColumn(randomBlock, bs.MaxWidth)
+	}
 
 	for _, location := range entity.BLOCK_OCCUPYING_LOCATION[randomBlock] {
 		if randomXCoordinate+int(location[0]) > bs.MaxWidth {
@@ -27,7 +61,7 @@ func (bs BlockSpawner) Spawn() (entity.BlockEntity, error) {
 		}
 	}
 
-	newEntity, err := entity.New(randomBlock, randomColor, [2]int{randomXCoordinate, 0})
+	newEntity, err := entity.New(randomBlock, entity.PIECE_COLORS[randomBlock], [2]int{randomXCoordinate, 0})
 
 	if err != nil {
 		return entity.BlockEntity{}, err
@@ -35,3 +69,14 @@ func (bs BlockSpawner) Spawn() (entity.BlockEntity, error) {
 
 	return newEntity, nil
 }
+
+// Column that puts the block in the middle of the top row, leaning left when it can't be exactly in the middle
+func centerColumn(block int, maxWidth int) int {
+	left, right := 0, 0
+	for _, location := range entity.BLOCK_OCCUPYING_LOCATION[block] {
+		left = min(left, location[0])
+		right = max(right, location[0])
+	}
+
+	return (maxWidth+1-(right-left+1))/2 - left
+}
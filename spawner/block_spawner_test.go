@@ -0,0 +1,28 @@
+package spawner
+
+import (
+	"math/rand"
+	"testing"
+	"tetris/entity"
+)
+
+func TestSpawnsEveryBlock(t *testing.T) {
+	blockSpawner := BlockSpawner{MaxWidth: 9, Randomizer: *rand.New(rand.NewSource(1))}
+	spawned := make(map[int]bool)
+
+	for range 1000 {
+		block, err := blockSpawner.Spawn()
+		if err != nil {
+			t.Error(err.Error())
+			t.FailNow()
+		}
+		spawned[block.EntityType] = true
+	}
+
+	for blockType := entity.I; blockType <= entity.Z; blockType++ {
+		if !spawned[blockType] {
+			t.Errorf("Block %d never spawned", blockType)
+			t.Fail()
+		}
+	}
+}
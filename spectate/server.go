@@ -0,0 +1,155 @@
+package spectate
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"sync"
+	"tetris/game"
+)
+
+const (
+	// a viewer that falls this many messages behind gets a fresh snapshot instead
+	CLIENT_BUFFER = 256
+)
+
+type client struct {
+	conn     net.Conn
+	messages chan Message
+}
+
+// Broadcasts a running game to every connected viewer
+type Server struct {
+	mutex    sync.Mutex
+	clients  map[*client]bool
+	frame    *Frame
+	sequence int
+	listener net.Listener
+}
+
+func NewServer() *Server {
+	return &Server{clients: make(map[*client]bool)}
+}
+
+// Accepts viewers until the listener is closed
+func (s *Server) Serve(listener net.Listener) error {
+	s.mutex.Lock()
+	s.listener = listener
+	s.mutex.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		s.add(conn)
+	}
+}
+
+// Late joiners start from the last published frame
+func (s *Server) add(conn net.Conn) {
+	viewer := &client{conn: conn, messages: make(chan Message, CLIENT_BUFFER)}
+
+	s.mutex.Lock()
+	if s.frame != nil {
+		snapshot := s.frame.Copy()
+		viewer.messages <- Message{Type: MESSAGE_SNAPSHOT, Sequence: s.sequence, Frame: &snapshot}
+	}
+	s.clients[viewer] = true
+	s.mutex.Unlock()
+
+	go s.write(viewer)
+}
+
+func (s *Server) write(viewer *client) {
+	encoder := json.NewEncoder(viewer.conn)
+
+	for message := range viewer.messages {
+		if err := encoder.Encode(message); err != nil {
+			s.remove(viewer)
+			break
+		}
+	}
+
+	viewer.conn.Close()
+}
+
+func (s *Server) remove(viewer *client) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.clients[viewer] {
+		delete(s.clients, viewer)
+		close(viewer.messages)
+	}
+}
+
+// Sends what changed since the last call to every viewer, meant to be called once per tick
+func (s *Server) Publish(tg *game.TetrisGame) {
+	frame := Capture(tg)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var message Message
+	if s.frame == nil {
+		snapshot := frame.Copy()
+		message = Message{Type: MESSAGE_SNAPSHOT, Frame: &snapshot}
+	} else if changes := Diff(*s.frame, frame); changes == nil {
+		snapshot := frame.Copy()
+		message = Message{Type: MESSAGE_SNAPSHOT, Frame: &snapshot}
+	} else if len(changes) == 0 && reflect.DeepEqual(s.frame.Status, frame.Status) {
+		return
+	} else {
+		status := frame.Status
+		message = Message{Type: MESSAGE_DELTA, Changes: changes, Status: &status}
+	}
+
+	s.sequence += 1
+	message.Sequence = s.sequence
+	s.frame = &frame
+
+	for viewer := range s.clients {
+		select {
+		case viewer.messages <- message:
+		default:
+			s.resync(viewer)
+		}
+	}
+}
+
+// The viewer can't keep up, the deltas it didn't get yet are replaced by a snapshot of the current frame
+func (s *Server) resync(viewer *client) {
+	for len(viewer.messages) > 0 {
+		select {
+		case <-viewer.messages:
+		default:
+		}
+	}
+
+	snapshot := s.frame.Copy()
+	viewer.messages <- Message{Type: MESSAGE_SNAPSHOT, Sequence: s.sequence, Frame: &snapshot}
+}
+
+func (s *Server) Viewers() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.clients)
+}
+
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for viewer := range s.clients {
+		delete(s.clients, viewer)
+		close(viewer.messages)
+	}
+
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+
+	return nil
+}
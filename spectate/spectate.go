@@ -0,0 +1,149 @@
+package spectate
+
+import (
+	"errors"
+	"fmt"
+	"tetris/board"
+	"tetris/game"
+	"tetris/matrix"
+)
+
+const (
+	MESSAGE_SNAPSHOT = "snapshot"
+	MESSAGE_DELTA    = "delta"
+)
+
+type Piece struct {
+	Type  int     `json:"type"`
+	Color int     `json:"color"`
+	Cells [][]int `json:"cells"`
+}
+
+// Everything about a game besides the board, small enough to be sent whole every time
+type Status struct {
+	Current *Piece `json:"current"`
+	Next    []int  `json:"next"`
+	Hold    int    `json:"hold"` // -1 when nothing is held
+	Score   int    `json:"score"`
+	Level   int    `json:"level"`
+	Lines   int    `json:"lines"`
+	Ticks   int    `json:"ticks"`
+	State   int    `json:"state"`
+}
+
+// What a viewer needs to draw a game
+type Frame struct {
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	Cells  [][]int `json:"cells"` // rows from top to bottom, -1 for empty cells
+	Status Status  `json:"status"`
+}
+
+// A snapshot carries the whole frame, a delta only the cells that changed since the previous message
+type Message struct {
+	Type     string   `json:"type"`
+	Sequence int      `json:"sequence"`
+	Frame    *Frame   `json:"frame,omitempty"`
+	Changes  [][3]int `json:"changes,omitempty"` // x, y and the new value of a cell
+	Status   *Status  `json:"status,omitempty"`
+}
+
+func Capture(tg *game.TetrisGame) Frame {
+	currentBoard := tg.Board()
+	frame := Frame{
+		Width:  currentBoard.Width,
+		Height: currentBoard.Height,
+		Cells:  currentBoard.Cells,
+		Status: Status{
+			Next:  make([]int, 0, len(tg.NextBlocks)),
+			Hold:  -1,
+			Score: tg.Score,
+			Level: tg.Level,
+			Lines: tg.Lines,
+			Ticks: tg.Ticks,
+			State: tg.State,
+		},
+	}
+
+	if tg.CurrentBlock != nil {
+		frame.Status.Current = &Piece{
+			Type:  tg.CurrentBlock.EntityType,
+			Color: tg.CurrentBlock.Color,
+			Cells: matrix.Copy(tg.CurrentBlock.OccupiedPosition),
+		}
+	}
+
+	for _, block := range tg.NextBlocks {
+		frame.Status.Next = append(frame.Status.Next, block.EntityType)
+	}
+
+	if tg.HoldBlock != nil {
+		frame.Status.Hold = tg.HoldBlock.EntityType
+	}
+
+	return frame
+}
+
+// Cells that differ between the two frames, nil when the board size changed and only a snapshot will do
+func Diff(previous, current Frame) [][3]int {
+	if previous.Width != current.Width || previous.Height != current.Height {
+		return nil
+	}
+
+	changes := make([][3]int, 0)
+	for y := range current.Height {
+		for x := range current.Width {
+			if previous.Cells[y][x] != current.Cells[y][x] {
+				changes = append(changes, [3]int{x, y, current.Cells[y][x]})
+			}
+		}
+	}
+
+	return changes
+}
+
+func (f Frame) Copy() Frame {
+	copiedFrame := f
+	copiedFrame.Cells = board.Board{Width: f.Width, Height: f.Height, Cells: f.Cells}.Copy().Cells
+
+	return copiedFrame
+}
+
+// Board of the frame, handy to place the projection of the current piece
+func (f Frame) Board() board.Board {
+	return board.Board{Width: f.Width, Height: f.Height, Cells: f.Cells}
+}
+
+// Brings the frame up to date with a message, deltas only apply on top of the message right before them
+func (f *Frame) Apply(message Message, lastSequence int) error {
+	if message.Type == MESSAGE_SNAPSHOT {
+		if message.Frame == nil {
+			return errors.New("Snapshot without a frame")
+		}
+
+		*f = message.Frame.Copy()
+		return nil
+	}
+
+	if message.Type != MESSAGE_DELTA {
+		return errors.New(fmt.Sprintf("Unknown message type %s", message.Type))
+	}
+
+	if message.Sequence != lastSequence+1 {
+		return errors.New(fmt.Sprintf("Expecting message %d, found %d", lastSequence+1, message.Sequence))
+	}
+
+	for _, change := range message.Changes {
+		x, y := change[0], change[1]
+		if !f.Board().ValidLocation(x, y) {
+			return errors.New(fmt.Sprintf("Change outside of the board x: %d y: %d", x, y))
+		}
+		f.Cells[y][x] = change[2]
+	}
+
+	if message.Status != nil {
+		f.Status = *message.Status
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package spectate
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"tetris/bot"
+	"tetris/game"
+	"time"
+)
+
+func newHeadlessGame(seed int64) *game.TetrisGame {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], seed)
+	tetrisGame.Start()
+
+	return &tetrisGame
+}
+
+func TestDeltasRebuildTheFrame(t *testing.T) {
+	tetrisGame := newHeadlessGame(1)
+	heuristicBot := bot.New(bot.DEFAULT_WEIGHTS)
+
+	previous := Capture(tetrisGame)
+	viewed := previous.Copy()
+
+	for tick := 1; tick <= 2000; tick++ {
+		tetrisGame.Update(heuristicBot.NextEvent(tetrisGame))
+		current := Capture(tetrisGame)
+		status := current.Status
+
+		err := viewed.Apply(Message{Type: MESSAGE_DELTA, Sequence: tick, Changes: Diff(previous, current), Status: &status}, tick-1)
+		if err != nil {
+			t.Errorf("Delta %d should apply, found %s", tick, err.Error())
+			t.FailNow()
+		}
+		previous = current
+	}
+
+	if !reflect.DeepEqual(viewed, previous) {
+		t.Error("Applying every delta should end up on the same frame as the game")
+		t.Fail()
+	}
+
+	if tetrisGame.Pieces == 0 {
+		t.Error("Bot should have placed some blocks")
+		t.Fail()
+	}
+}
+
+func TestDeltaOutOfOrder(t *testing.T) {
+	frame := Capture(newHeadlessGame(1))
+
+	if err := frame.Apply(Message{Type: MESSAGE_DELTA, Sequence: 5}, 3); err == nil {
+		t.Error("Delta that skips a message should not apply")
+		t.Fail()
+	}
+}
+
+func TestProjection(t *testing.T) {
+	frame := Capture(newHeadlessGame(1))
+	frame.Cells[frame.Height-1][0] = 0
+
+	projection := Projection(frame.Board(), [][]int{{0, 0}, {1, 0}})
+	if projection[0][1] != frame.Height-2 || projection[1][1] != frame.Height-2 {
+		t.Errorf("Projection should rest on the block in the last row, found %v", projection)
+		t.Fail()
+	}
+}
+
+// Waits until the viewer caught up with the given sequence
+func waitFor(t *testing.T, viewer *Viewer, sequence int) Frame {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		frame, received := viewer.Frame()
+		if received == sequence {
+			return frame
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Errorf("Viewer should reach message %d", sequence)
+	t.FailNow()
+	return Frame{}
+}
+
+func TestLateJoinerCatchesUp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	server := NewServer()
+	defer server.Close()
+	go server.Serve(listener)
+
+	tetrisGame := newHeadlessGame(2)
+	heuristicBot := bot.New(bot.DEFAULT_WEIGHTS)
+	play := func(ticks int) {
+		for range ticks {
+			tetrisGame.Update(heuristicBot.NextEvent(tetrisGame))
+			server.Publish(tetrisGame)
+		}
+	}
+
+	play(300)
+	early, err := Watch(listener.Addr().String())
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+	defer early.Close()
+
+	play(300)
+	late, err := Watch(listener.Addr().String())
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+	defer late.Close()
+
+	play(300)
+	expected := Capture(tetrisGame)
+
+	for _, viewer := range []*Viewer{early, late} {
+		frame := waitFor(t, viewer, server.sequence)
+		if !reflect.DeepEqual(frame, expected) {
+			t.Error("Viewer should see the same frame as the game")
+			t.Fail()
+		}
+	}
+
+	if server.Viewers() != 2 {
+		t.Errorf("Server should have 2 viewers, found %d", server.Viewers())
+		t.Fail()
+	}
+}
@@ -0,0 +1,172 @@
+package spectate
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"tetris/board"
+	"tetris/game"
+	"tetris/matrix"
+	renderer "tetris/ui"
+	"time"
+)
+
+// Follows a game broadcast by a Server and draws it with the normal renderer
+type Viewer struct {
+	Renderer renderer.Renderer
+	Err      error
+	mutex    sync.Mutex
+	frame    Frame
+	sequence int
+	received bool
+	conn     net.Conn
+}
+
+// Connects and waits for the first snapshot, the board size is only known after it
+func Watch(address string) (*Viewer, error) {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer := &Viewer{conn: conn}
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	if err := viewer.read(scanner); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	go func() {
+		for {
+			if err := viewer.read(scanner); err != nil {
+				viewer.mutex.Lock()
+				viewer.Err = err
+				viewer.mutex.Unlock()
+				return
+			}
+		}
+	}()
+
+	return viewer, nil
+}
+
+func (v *Viewer) read(scanner *bufio.Scanner) error {
+	if !scanner.Scan() {
+		if scanner.Err() != nil {
+			return scanner.Err()
+		}
+		return errors.New("Server closed the connection")
+	}
+
+	var message Message
+	if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+		return err
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if !v.received && message.Type != MESSAGE_SNAPSHOT {
+		return errors.New("Expecting a snapshot before any delta")
+	}
+
+	if err := v.frame.Apply(message, v.sequence); err != nil {
+		return err
+	}
+	v.sequence = message.Sequence
+	v.received = true
+
+	return nil
+}
+
+// Copy of the latest frame, safe to use while new messages keep coming in
+func (v *Viewer) Frame() (Frame, int) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	frame := v.frame.Copy()
+	return frame, v.sequence
+}
+
+func (v *Viewer) Close() error {
+	return v.conn.Close()
+}
+
+func (v *Viewer) Play() {
+	frame, _ := v.Frame()
+	v.Renderer.TotalHorizontalBlock = frame.Width - 1
+	v.Renderer.TotalVerticalBlock = frame.Height - 1
+	v.Renderer.Init("Tetris Spectator")
+	defer v.Renderer.Close()
+
+	for !v.Renderer.ShouldClose() {
+		frame, _ := v.Frame()
+		v.Render(frame)
+	}
+}
+
+func (v *Viewer) Render(frame Frame) {
+	if frame.Status.State == game.LOSE {
+		v.Renderer.RenderLose(frame.Status.Score)
+		return
+	}
+
+	blocks := make([][2]float32, 0)
+	colors := make([][]int, frame.Width)
+	for x := range colors {
+		colors[x] = make([]int, frame.Height)
+	}
+
+	for y, row := range frame.Cells {
+		for x, value := range row {
+			if value != board.EMPTY {
+				blocks = append(blocks, [2]float32{float32(x), float32(y)})
+				colors[x][y] = value
+			}
+		}
+	}
+
+	projection := make([][2]float32, 0)
+	projectionColor := -1
+
+	if current := frame.Status.Current; current != nil {
+		for _, location := range current.Cells {
+			if frame.Board().ValidLocation(location[0], location[1]) {
+				blocks = append(blocks, [2]float32{float32(location[0]), float32(location[1])})
+				colors[location[0]][location[1]] = current.Color
+			}
+		}
+
+		for _, location := range Projection(frame.Board(), current.Cells) {
+			projection = append(projection, [2]float32{float32(location[0]), float32(location[1])})
+		}
+		projectionColor = current.Color
+	}
+
+	elapsedTime := time.Duration(frame.Status.Ticks) * time.Second / game.TICKS_PER_SECOND
+	v.Renderer.RenderPlay(blocks, colors, projection, projectionColor, 0, frame.Status.Level, frame.Status.Score, elapsedTime)
+}
+
+// Where the piece would land when dropped straight down
+func Projection(currentBoard board.Board, cells matrix.Matrix) matrix.Matrix {
+	projection := matrix.Copy(cells)
+	if len(projection) == 0 {
+		return projection
+	}
+
+	for {
+		dropped := matrix.Copy(projection)
+		for _, location := range dropped {
+			location[1] += 1
+		}
+
+		if !currentBoard.Fits(dropped) {
+			return projection
+		}
+		projection = dropped
+	}
+}
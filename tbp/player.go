@@ -0,0 +1,179 @@
+package tbp
+
+import (
+	"errors"
+	"fmt"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	movegenerator "tetris/move_generator"
+)
+
+// Applies the moves chosen by the bot to a game, can be plugged in as the input source of a game
+type Player struct {
+	Frontend     *Frontend
+	Game         *game.TetrisGame
+	Err          error
+	started      bool
+	queue        []string // queue of the bot, the pieces it was told about and didn't play yet
+	currentBlock *entity.BlockEntity
+	move         *Move
+	follower     *movegenerator.Follower
+}
+
+func (p *Player) HandleEvent() eventhandler.UpdateEvent {
+	event, err := p.NextEvent()
+	if err != nil {
+		p.Err = err
+		p.Game.State = game.LOSE
+	}
+
+	return event
+}
+
+func (p *Player) NextEvent() (eventhandler.UpdateEvent, error) {
+	tg := p.Game
+	if tg.State != game.PLAY || tg.BlockState != game.MOVING_BLOCK || tg.CurrentBlock == nil {
+		return eventhandler.UpdateEvent{}, nil
+	}
+
+	if p.currentBlock != tg.CurrentBlock {
+		p.currentBlock = tg.CurrentBlock
+
+		// holding brings in a new block but it is still the same turn for the bot
+		if p.move == nil {
+			if err := p.choose(); err != nil {
+				return eventhandler.UpdateEvent{}, err
+			}
+		}
+
+		if PIECE_NAMES[tg.CurrentBlock.EntityType] != p.move.Location.Type {
+			return eventhandler.UpdateEvent{Hold: true}, nil
+		}
+
+		target, _ := LocationCells(p.move.Location, tg.MaxHeight+1)
+		follower, err := movegenerator.NewFollower(tg.Board(), *tg.CurrentBlock, target)
+		if err != nil {
+			return eventhandler.UpdateEvent{}, err
+		}
+		p.follower = &follower
+		p.move = nil
+	}
+
+	if p.follower == nil {
+		return eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN}, nil
+	}
+
+	event, err := p.follower.NextEvent(tg.Board(), *tg.CurrentBlock)
+	if err != nil {
+		return eventhandler.UpdateEvent{MovingDirection: eventhandler.DOWN}, nil
+	}
+
+	return event, nil
+}
+
+// Catches the bot up on the queue, asks for a move and plays the first one the game can actually reach
+func (p *Player) choose() error {
+	tg := p.Game
+	pieces := queue(tg)
+
+	if !p.started {
+		if err := p.Frontend.Start(tg); err != nil {
+			return err
+		}
+		p.started = true
+		p.queue = pieces
+	}
+
+	// the queue of the bot is the start of the one of the game, it only misses the pieces that came in since
+	for _, piece := range pieces[min(len(p.queue), len(pieces)):] {
+		if err := p.Frontend.NewPiece(piece); err != nil {
+			return err
+		}
+	}
+	p.queue = pieces
+
+	moves, err := p.Frontend.Suggest()
+	if err != nil {
+		return err
+	}
+
+	reachable := make(map[string]map[string]bool)
+
+	for _, move := range moves {
+		block := tg.CurrentBlock
+		if PIECE_NAMES[block.EntityType] != move.Location.Type {
+			// the other block is only reachable by holding first
+			if tg.HoldBlock != nil {
+				block = tg.HoldBlock
+			} else if len(tg.NextBlocks) > 0 {
+				block = &tg.NextBlocks[0]
+			}
+
+			if PIECE_NAMES[block.EntityType] != move.Location.Type {
+				continue
+			}
+		}
+
+		target, err := LocationCells(move.Location, tg.MaxHeight+1)
+		if err != nil {
+			continue
+		}
+
+		placements, ok := reachable[move.Location.Type]
+		if !ok {
+			placements = p.placements(*block)
+			reachable[move.Location.Type] = placements
+		}
+
+		if placements[movegenerator.PositionKey(target)] {
+			if err := p.Frontend.Play(move); err != nil {
+				return err
+			}
+			// playing takes the piece off the queue, holding into an empty hold the one after it as well
+			played := 1
+			if PIECE_NAMES[tg.CurrentBlock.EntityType] != move.Location.Type && tg.HoldBlock == nil {
+				played = 2
+			}
+			p.queue = p.queue[min(played, len(p.queue)):]
+			p.move = &move
+			return nil
+		}
+	}
+
+	return errors.New(fmt.Sprintf("None of the %d suggested moves can be reached", len(moves)))
+}
+
+// The held block comes back on a different spot than the current one, so the search starts from a fresh spawn
+func (p *Player) placements(block entity.BlockEntity) map[string]bool {
+	tg := p.Game
+	start := block
+
+	if tg.CurrentBlock.EntityType != block.EntityType {
+		spawnedBlock, err := entity.New(block.EntityType, block.Color, [2]int{tg.CurrentBlock.OccupiedPosition[0][0], 0})
+		if err != nil {
+			return map[string]bool{}
+		}
+		start = spawnedBlock
+	}
+
+	placements := make(map[string]bool)
+	for _, placement := range movegenerator.Generate(tg.Board(), start) {
+		placements[movegenerator.PositionKey(placement.Position)] = true
+	}
+
+	return placements
+}
+
+// Plays headlessly until the game is lost or maxPieces are placed
+func (p *Player) Run(maxPieces int) error {
+	for p.Game.State == game.PLAY && p.Game.Pieces < maxPieces {
+		event, err := p.NextEvent()
+		if err != nil {
+			return err
+		}
+		p.Game.Update(event)
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package tbp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"tetris/board"
+	"tetris/entity"
+	"tetris/game"
+	"tetris/matrix"
+)
+
+// The protocol always describes a 10 wide board with 40 rows, row 0 being the bottom one
+const (
+	BOARD_WIDTH  = 10
+	BOARD_HEIGHT = 40
+)
+
+var PIECE_NAMES map[int]string = map[int]string{
+	entity.I: "I",
+	entity.J: "J",
+	entity.L: "L",
+	entity.O: "O",
+	entity.S: "S",
+	entity.T: "T",
+	entity.Z: "Z",
+}
+
+// Cells of every piece in the north orientation relative to its center, y goes up
+var PIECE_CELLS map[string][][2]int = map[string][][2]int{
+	"I": {{-1, 0}, {0, 0}, {1, 0}, {2, 0}},
+	"J": {{-1, 0}, {0, 0}, {1, 0}, {-1, 1}},
+	"L": {{-1, 0}, {0, 0}, {1, 0}, {1, 1}},
+	"O": {{0, 0}, {1, 0}, {0, 1}, {1, 1}},
+	"S": {{-1, 0}, {0, 0}, {0, 1}, {1, 1}},
+	"T": {{-1, 0}, {0, 0}, {1, 0}, {0, 1}},
+	"Z": {{-1, 1}, {0, 1}, {0, 0}, {1, 0}},
+}
+
+type Location struct {
+	Type        string `json:"type"`
+	Orientation string `json:"orientation"`
+	X           int    `json:"x"`
+	Y           int    `json:"y"`
+}
+
+type Move struct {
+	Location Location `json:"location"`
+	Spin     string   `json:"spin"`
+}
+
+type Message struct {
+	Type     string      `json:"type"`
+	Name     string      `json:"name,omitempty"`
+	Version  string      `json:"version,omitempty"`
+	Author   string      `json:"author,omitempty"`
+	Features []string    `json:"features,omitempty"`
+	Reason   string      `json:"reason,omitempty"`
+	Moves    []Move      `json:"moves,omitempty"`
+	Move     *Move       `json:"move,omitempty"`
+	Piece    string      `json:"piece,omitempty"`
+	Hold     *string     `json:"hold,omitempty"`
+	Queue    []string    `json:"queue,omitempty"`
+	Board    [][]*string `json:"board,omitempty"`
+}
+
+// The start message has to carry every field, an empty hold is sent as null
+type StartMessage struct {
+	Type       string      `json:"type"`
+	Hold       *string     `json:"hold"`
+	Queue      []string    `json:"queue"`
+	Combo      int         `json:"combo"`
+	BackToBack bool        `json:"back_to_back"`
+	Board      [][]*string `json:"board"`
+}
+
+// Talks to a bot following the Tetris Bot Protocol, the game acts as the frontend
+type Frontend struct {
+	Info    Message
+	encoder *json.Encoder
+	scanner *bufio.Scanner
+	closer  io.Closer
+	command *exec.Cmd
+}
+
+// Starts the bot process and exchanges the rules with it
+func Launch(name string, args ...string) (*Frontend, error) {
+	command := exec.Command(name, args...)
+
+	botInput, err := command.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	botOutput, err := command.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := command.Start(); err != nil {
+		return nil, err
+	}
+
+	frontend, err := Connect(botInput, botOutput)
+	if err != nil {
+		command.Process.Kill()
+		return nil, err
+	}
+	frontend.command = command
+
+	return frontend, nil
+}
+
+// Same as Launch but for a bot that is already running behind the given streams
+func Connect(botInput io.WriteCloser, botOutput io.Reader) (*Frontend, error) {
+	scanner := bufio.NewScanner(botOutput)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	frontend := &Frontend{encoder: json.NewEncoder(botInput), scanner: scanner, closer: botInput}
+
+	info, err := frontend.receive("info")
+	if err != nil {
+		return nil, err
+	}
+	frontend.Info = info
+
+	if err := frontend.send(Message{Type: "rules"}); err != nil {
+		return nil, err
+	}
+
+	if _, err := frontend.receive("ready"); err != nil {
+		return nil, err
+	}
+
+	return frontend, nil
+}
+
+func (f *Frontend) send(message any) error {
+	return f.encoder.Encode(message)
+}
+
+func (f *Frontend) receive(expectedType string) (Message, error) {
+	if !f.scanner.Scan() {
+		if f.scanner.Err() != nil {
+			return Message{}, f.scanner.Err()
+		}
+		return Message{}, errors.New(fmt.Sprintf("Bot closed the connection while waiting for %s", expectedType))
+	}
+
+	var message Message
+	if err := json.Unmarshal(f.scanner.Bytes(), &message); err != nil {
+		return Message{}, err
+	}
+
+	if message.Type == "error" {
+		return message, errors.New(fmt.Sprintf("Bot refused with reason %s", message.Reason))
+	}
+
+	if message.Type != expectedType {
+		return message, errors.New(fmt.Sprintf("Expecting %s message from the bot, found %s instead", expectedType, message.Type))
+	}
+
+	return message, nil
+}
+
+func (f *Frontend) Start(tg *game.TetrisGame) error {
+	if tg.MaxWitdh+1 != BOARD_WIDTH || tg.MaxHeight+1 > BOARD_HEIGHT {
+		return errors.New(fmt.Sprintf("Bot protocol needs a board %d wide and at most %d high", BOARD_WIDTH, BOARD_HEIGHT))
+	}
+
+	// the game keeps no combo or back to back between games, every game starts without them
+	start := StartMessage{Type: "start", Queue: queue(tg), Board: encodeBoard(tg.Board())}
+	if tg.HoldBlock != nil {
+		hold := PIECE_NAMES[tg.HoldBlock.EntityType]
+		start.Hold = &hold
+	}
+
+	return f.send(start)
+}
+
+func (f *Frontend) Suggest() ([]Move, error) {
+	if err := f.send(Message{Type: "suggest"}); err != nil {
+		return nil, err
+	}
+
+	suggestion, err := f.receive("suggestion")
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestion.Moves, nil
+}
+
+func (f *Frontend) Play(move Move) error {
+	return f.send(Message{Type: "play", Move: &move})
+}
+
+func (f *Frontend) NewPiece(piece string) error {
+	return f.send(Message{Type: "new_piece", Piece: piece})
+}
+
+func (f *Frontend) Stop() error {
+	return f.send(Message{Type: "stop"})
+}
+
+func (f *Frontend) Close() error {
+	f.send(Message{Type: "quit"})
+	f.closer.Close()
+
+	if f.command != nil {
+		return f.command.Wait()
+	}
+
+	return nil
+}
+
+// Current block followed by the preview, the way the protocol expects the queue
+func queue(tg *game.TetrisGame) []string {
+	pieces := make([]string, 0, len(tg.NextBlocks)+1)
+
+	if tg.CurrentBlock != nil {
+		pieces = append(pieces, PIECE_NAMES[tg.CurrentBlock.EntityType])
+	}
+
+	for _, block := range tg.NextBlocks {
+		pieces = append(pieces, PIECE_NAMES[block.EntityType])
+	}
+
+	return pieces
+}
+
+// The board doesn't remember which piece filled a cell, those are sent as garbage
+func encodeBoard(currentBoard board.Board) [][]*string {
+	garbage := "G"
+	rows := make([][]*string, BOARD_HEIGHT)
+
+	for row := range BOARD_HEIGHT {
+		rows[row] = make([]*string, BOARD_WIDTH)
+		y := currentBoard.Height - 1 - row
+
+		for x := range BOARD_WIDTH {
+			if y >= 0 && currentBoard.Occupied(x, y) {
+				rows[row][x] = &garbage
+			}
+		}
+	}
+
+	return rows
+}
+
+// Cells taken by the location in board coordinates, where y goes down
+func LocationCells(location Location, boardHeight int) (matrix.Matrix, error) {
+	cells, ok := PIECE_CELLS[location.Type]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Unknown piece %s", location.Type))
+	}
+
+	position := make(matrix.Matrix, len(cells))
+
+	for i, cell := range cells {
+		x, y := cell[0], cell[1]
+
+		switch location.Orientation {
+		case "north":
+		case "east":
+			x, y = y, -x
+		case "south":
+			x, y = -x, -y
+		case "west":
+			x, y = -y, x
+		default:
+			return nil, errors.New(fmt.Sprintf("Unknown orientation %s", location.Orientation))
+		}
+
+		position[i] = []int{location.X + x, boardHeight - 1 - (location.Y + y)}
+	}
+
+	return position, nil
+}
@@ -0,0 +1,184 @@
+package tbp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+	"tetris/game"
+	"tetris/matrix"
+	movegenerator "tetris/move_generator"
+)
+
+// Bot that doesn't look at the board, it suggests every location of the current piece from the bottom up
+func runStubBot(in io.Reader, out io.Writer) {
+	encoder := json.NewEncoder(out)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	pieces := make([]string, 0)
+	var hold *string
+
+	encoder.Encode(Message{Type: "info", Name: "stub", Version: "1", Author: "tests", Features: []string{}})
+
+	for scanner.Scan() {
+		var message Message
+		json.Unmarshal(scanner.Bytes(), &message)
+
+		switch message.Type {
+		case "rules":
+			encoder.Encode(Message{Type: "ready"})
+		case "start":
+			pieces = message.Queue
+			hold = message.Hold
+		case "new_piece":
+			pieces = append(pieces, message.Piece)
+		case "suggest":
+			moves := make([]Move, 0)
+			for y := range BOARD_HEIGHT {
+				for x := range BOARD_WIDTH {
+					for _, orientation := range []string{"north", "east", "south", "west"} {
+						moves = append(moves, Move{Location: Location{Type: pieces[0], Orientation: orientation, X: x, Y: y}, Spin: "none"})
+					}
+				}
+			}
+			encoder.Encode(Message{Type: "suggestion", Moves: moves})
+		case "play":
+			if message.Move.Location.Type != pieces[0] {
+				if hold == nil {
+					pieces = pieces[1:]
+				}
+				hold = &pieces[0]
+			}
+			pieces = pieces[1:]
+		case "quit":
+			return
+		}
+	}
+}
+
+func TestHelperStubBot(t *testing.T) {
+	if os.Getenv("TBP_STUB_BOT") != "1" {
+		return
+	}
+
+	runStubBot(os.Stdin, os.Stdout)
+	os.Exit(0)
+}
+
+func TestLocationCells(t *testing.T) {
+	cells, err := LocationCells(Location{Type: "T", Orientation: "north", X: 4, Y: 0}, 20)
+	expectedCells := matrix.Matrix{{3, 19}, {4, 19}, {5, 19}, {4, 18}}
+
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if movegenerator.PositionKey(cells) != movegenerator.PositionKey(expectedCells) {
+		t.Errorf("T block pointing up should be on the bottom row, found %s", cells.ToString())
+		t.Fail()
+	}
+
+	cells, _ = LocationCells(Location{Type: "I", Orientation: "east", X: 0, Y: 2}, 20)
+	expectedCells = matrix.Matrix{{0, 16}, {0, 17}, {0, 18}, {0, 19}}
+
+	if movegenerator.PositionKey(cells) != movegenerator.PositionKey(expectedCells) {
+		t.Errorf("I block rotated to the east should stand up, found %s", cells.ToString())
+		t.Fail()
+	}
+}
+
+func TestPlayerShouldPlaceSuggestedMoves(t *testing.T) {
+	botInput, frontendOutput := io.Pipe()
+	frontendInput, botOutput := io.Pipe()
+	go runStubBot(botInput, botOutput)
+
+	frontend, err := Connect(frontendOutput, frontendInput)
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+	defer frontend.Close()
+
+	if frontend.Info.Name != "stub" {
+		t.Errorf("Bot info should be read on connect, found %s", frontend.Info.Name)
+		t.Fail()
+	}
+
+	tetrisGame := game.NewHeadless(9, 19, 5)
+	tetrisGame.Start()
+	player := Player{Frontend: frontend, Game: &tetrisGame}
+
+	if err := player.Run(10); err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if tetrisGame.Pieces != 10 {
+		t.Errorf("Bot should have placed 10 pieces, found %d", tetrisGame.Pieces)
+		t.Fail()
+	}
+}
+
+func TestStartShouldRejectOtherBoardWidth(t *testing.T) {
+	botInput, frontendOutput := io.Pipe()
+	frontendInput, botOutput := io.Pipe()
+	go runStubBot(botInput, botOutput)
+
+	frontend, _ := Connect(frontendOutput, frontendInput)
+	defer frontend.Close()
+
+	tetrisGame := game.NewHeadless(10, 19, 5)
+	if err := frontend.Start(&tetrisGame); err == nil {
+		t.Error("Board with 11 columns can't be described with the protocol")
+		t.Fail()
+	}
+}
+
+func TestLaunch(t *testing.T) {
+	os.Setenv("TBP_STUB_BOT", "1")
+	defer os.Unsetenv("TBP_STUB_BOT")
+
+	frontend, err := Launch(os.Args[0], "-test.run=TestHelperStubBot")
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if frontend.Info.Name != "stub" {
+		t.Errorf("Bot info should be read on launch, found %s", frontend.Info.Name)
+		t.Fail()
+	}
+
+	if err := frontend.Close(); err != nil {
+		t.Error(err.Error())
+		t.Fail()
+	}
+}
+
+func TestStartOfAnEmptyGame(t *testing.T) {
+	var output bytes.Buffer
+	frontend := Frontend{encoder: json.NewEncoder(&output)}
+
+	tetrisGame := game.NewHeadless(9, 19, 5)
+	if err := frontend.Start(&tetrisGame); err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	var start map[string]json.RawMessage
+	if err := json.Unmarshal(output.Bytes(), &start); err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	expected := map[string]string{"hold": "null", "combo": "0", "back_to_back": "false", "queue": "[]"}
+	for field, value := range expected {
+		if string(start[field]) != value {
+			t.Errorf("Start should have %s %s, found %s in %s", field, value, start[field], output.String())
+			t.Fail()
+		}
+	}
+}
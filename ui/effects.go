@@ -0,0 +1,132 @@
+package renderer
+
+import (
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	LOCK_FLASH_DURATION = 120 * time.Millisecond
+	TRAIL_DURATION      = 200 * time.Millisecond
+	// how long cleared rows light up when the rules don't hold the game for them
+	CLEAR_FLASH_DURATION = 200 * time.Millisecond
+	// part of the line clear delay the rows blink for, they collapse during the rest
+	CLEAR_BLINK_PART = 0.6
+	CLEAR_BLINKS     = 3
+)
+
+// Animations drawn over the board. They run on the wall clock and only look at what the game already did,
+// the game never waits for them.
+type effects struct {
+	lockedCells   [][2]float32
+	lockedAt      time.Time
+	trail         [][2]float32 // cells the hard dropped block started from
+	trailLength   float32
+	trailColor    int
+	trailAt       time.Time
+	clearedRows   []int
+	clearedAt     time.Time
+	clearDuration time.Duration
+}
+
+// Lights up the cells of a block that just locked
+func (r *Renderer) LockFlash(cells [][2]float32) {
+	r.effects.lockedCells = append([][2]float32(nil), cells...)
+	r.effects.lockedAt = time.Now()
+}
+
+// Streak from where a block got hard dropped down to where it landed, distance in rows
+func (r *Renderer) HardDropTrail(from [][2]float32, distance float32, colorID int) {
+	r.effects.trail = append([][2]float32(nil), from...)
+	r.effects.trailLength = distance
+	r.effects.trailColor = colorID
+	r.effects.trailAt = time.Now()
+}
+
+// Rows blink and then collapse for the line clear delay while they are still on the board.
+// Without a delay they are already gone and only light up for a moment where they were.
+func (r *Renderer) ClearRows(rows []int, delay time.Duration) {
+	r.effects.clearedRows = append([]int(nil), rows...)
+	r.effects.clearedAt = time.Now()
+	r.effects.clearDuration = delay
+}
+
+func (r Renderer) drawEffects() {
+	r.drawTrail()
+	r.drawLockFlash()
+	r.drawClearedRows()
+}
+
+func (r Renderer) drawTrail() {
+	remaining := 1 - float32(time.Since(r.effects.trailAt))/float32(TRAIL_DURATION)
+	if remaining <= 0 || r.effects.trailLength == 0 {
+		return
+	}
+
+	for _, cell := range r.effects.trail {
+		x, y := r.cellPosition(cell[0], cell[1])
+		rl.DrawRectangleV(
+			rl.Vector2{X: x, Y: y},
+			rl.Vector2{X: float32(r.BlockXSize), Y: float32(r.BlockYSize) * r.effects.trailLength},
+			rl.Fade(r.blockColor(r.effects.trailColor), 0.3*remaining),
+		)
+	}
+}
+
+func (r Renderer) drawLockFlash() {
+	remaining := 1 - float32(time.Since(r.effects.lockedAt))/float32(LOCK_FLASH_DURATION)
+	if remaining <= 0 {
+		return
+	}
+
+	for _, cell := range r.effects.lockedCells {
+		x, y := r.cellPosition(cell[0], cell[1])
+		rl.DrawRectangleV(rl.Vector2{X: x, Y: y}, rl.Vector2{X: float32(r.BlockXSize), Y: float32(r.BlockYSize)}, rl.Fade(rl.White, 0.6*remaining))
+	}
+}
+
+func (r Renderer) drawClearedRows() {
+	elapsed := time.Since(r.effects.clearedAt)
+	rowWidth := float32(r.BlockXSize) * float32(r.TotalHorizontalBlock+1)
+
+	if r.effects.clearDuration == 0 {
+		remaining := 1 - float32(elapsed)/float32(CLEAR_FLASH_DURATION)
+		if remaining <= 0 {
+			return
+		}
+
+		for _, row := range r.effects.clearedRows {
+			x, y := r.cellPosition(0, float32(row))
+			rl.DrawRectangleV(rl.Vector2{X: x, Y: y}, rl.Vector2{X: rowWidth, Y: float32(r.BlockYSize)}, rl.Fade(rl.White, 0.5*remaining))
+		}
+		return
+	}
+
+	progress := float32(elapsed) / float32(r.effects.clearDuration)
+	if progress >= 1 {
+		return
+	}
+
+	for _, row := range r.effects.clearedRows {
+		x, y := r.cellPosition(0, float32(row))
+
+		if progress < CLEAR_BLINK_PART {
+			alpha := float32(0.2)
+			if int(progress/CLEAR_BLINK_PART*CLEAR_BLINKS*2)%2 == 0 {
+				alpha = 0.7
+			}
+			rl.DrawRectangleV(rl.Vector2{X: x, Y: y}, rl.Vector2{X: rowWidth, Y: float32(r.BlockYSize)}, rl.Fade(rl.White, alpha))
+			continue
+		}
+
+		// the row disappears from its middle outwards
+		collapsed := rowWidth * (progress - CLEAR_BLINK_PART) / (1 - CLEAR_BLINK_PART)
+		rl.DrawRectangleV(rl.Vector2{X: x + rowWidth/2 - collapsed/2, Y: y}, rl.Vector2{X: collapsed, Y: float32(r.BlockYSize)}, r.look().background)
+	}
+}
+
+// Top left corner of a cell of the board in the window
+func (r Renderer) cellPosition(x, y float32) (float32, float32) {
+	return float32(r.BlockXSize)*x + float32(r.xOffset), float32(r.BlockYSize)*y + float32(r.yOffset)
+}
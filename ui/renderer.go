@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"fmt"
+	"math"
 	"tetris/entity"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 
 const (
 	TEXT_SCORE_DURATION_SECOND = 2
+	FLASH_DURATION             = 150 * time.Millisecond
 )
 
 var BLOCK_COLORS map[int]rl.Color = map[int]rl.Color{
@@ -17,6 +19,10 @@ var BLOCK_COLORS map[int]rl.Color = map[int]rl.Color{
 	entity.BLUE:   rl.Blue,
 	entity.YELLOW: rl.Yellow,
 	entity.GREEN:  rl.Green,
+	entity.GRAY:   rl.Gray,
+	entity.CYAN:   rl.SkyBlue,
+	entity.ORANGE: rl.Orange,
+	entity.PURPLE: rl.Purple,
 }
 
 type Renderer struct {
@@ -27,10 +33,13 @@ type Renderer struct {
 	TotalHorizontalBlock int
 	TotalVerticalBlock   int
 	TargetFps            int32
+	Themes               *Themes // guideline look when nil
 	xOffset              int32
 	yOffset              int32
 	currentGainedScore   int
 	timeGainedScore      time.Time
+	flashUntil           time.Time
+	effects              effects
 }
 
 func (r *Renderer) Init(gameName string) {
@@ -41,6 +50,10 @@ func (r *Renderer) Init(gameName string) {
 	r.yOffset = r.Height/2 - r.BlockYSize*int32(r.TotalVerticalBlock)/2
 	rl.InitWindow(r.Width, r.Height, gameName)
 	rl.SetTargetFPS(r.TargetFps)
+
+	if r.Themes != nil {
+		r.Themes.load()
+	}
 }
 
 func (r *Renderer) RenderPlay(
@@ -53,8 +66,32 @@ func (r *Renderer) RenderPlay(
 	currentScore int,
 	elapsedTime time.Duration) {
 	rl.BeginDrawing()
-	rl.ClearBackground(rl.Black)
+	rl.ClearBackground(r.look().background)
+
+	r.DrawBoard(blockPositions, color, blockProjectionPos, currentBlockColor)
+
+	if gainedScore > 0 {
+		r.currentGainedScore = gainedScore
+		r.RenderGainedScore(gainedScore)
+		r.timeGainedScore = time.Now()
+	} else if time.Now().Sub(r.timeGainedScore).Seconds() < TEXT_SCORE_DURATION_SECOND {
+		r.RenderGainedScore(r.currentGainedScore)
+	}
+
+	r.RenderTimeElapsed(elapsedTime)
+	r.RenderScore(currentScore)
+	r.RenderLevel(level)
+	r.drawFlash()
+	rl.EndDrawing()
+}
 
+// Draws the grid, the projection and the blocks without starting a new frame,
+// so that several boards can share the same frame
+func (r Renderer) DrawBoard(
+	blockPositions [][2]float32,
+	color [][]int,
+	blockProjectionPos [][2]float32,
+	currentBlockColor int) {
 	for i := range r.TotalHorizontalBlock + 1 {
 		for j := range r.TotalVerticalBlock + 1 {
 			rl.DrawRectangleLines(
@@ -62,7 +99,7 @@ func (r *Renderer) RenderPlay(
 				r.BlockYSize*int32(j)+r.yOffset,
 				r.BlockXSize,
 				r.BlockYSize,
-				rl.Gray,
+				r.look().grid,
 			)
 		}
 	}
@@ -71,13 +108,7 @@ func (r *Renderer) RenderPlay(
 		xPosition := float32(r.BlockXSize)*blockProjectionPos[i][0] + float32(r.xOffset)
 		yPosition := float32(r.BlockYSize)*blockProjectionPos[i][1] + float32(r.yOffset)
 
-		rl.DrawRectangleLines(
-			int32(xPosition),
-			int32(yPosition),
-			r.BlockXSize,
-			r.BlockYSize,
-			BLOCK_COLORS[currentBlockColor],
-		)
+		r.drawGhostCell(xPosition, yPosition, currentBlockColor)
 	}
 
 	for i := range blockPositions {
@@ -86,46 +117,143 @@ func (r *Renderer) RenderPlay(
 		yPosition := float32(r.BlockYSize)*blockPositions[i][1] + float32(r.yOffset)
 		blockColor := color[int(blockPositions[i][0])][int(blockPositions[i][1])]
 
-		rl.DrawRectangleV(
-			rl.Vector2{X: float32(xPosition), Y: float32(yPosition)},
-			rl.Vector2{X: float32(r.BlockXSize), Y: float32(r.BlockYSize)},
-			BLOCK_COLORS[blockColor],
-		)
+		r.drawCell(xPosition, yPosition, blockColor)
 	}
-	if gainedScore > 0 {
-		r.currentGainedScore = gainedScore
-		r.RenderGainedScore(gainedScore)
-		r.timeGainedScore = time.Now()
-	} else if time.Now().Sub(r.timeGainedScore).Seconds() < TEXT_SCORE_DURATION_SECOND {
-		r.RenderGainedScore(r.currentGainedScore)
+
+	r.drawEffects()
+}
+
+// Moves the board away from the center of the window
+func (r *Renderer) SetBoardOrigin(x, y int32) {
+	r.xOffset = x
+	r.yOffset = y
+}
+
+// Origins of count boards spread evenly over the width of the window
+func (r Renderer) BoardOrigins(count int) [][2]int32 {
+	origins := make([][2]int32, count)
+	boardWidth := r.BlockXSize * int32(r.TotalHorizontalBlock+1)
+	boardHeight := r.BlockYSize * int32(r.TotalVerticalBlock+1)
+	columnWidth := r.Width / int32(count)
+
+	for i := range count {
+		origins[i][0] = columnWidth*int32(i) + columnWidth/2 - boardWidth/2
+		origins[i][1] = r.Height/2 - boardHeight/2
+	}
+
+	return origins
+}
+
+// Board cell under a point of the window, false when the point is outside of the board
+func (r Renderer) CellAt(x, y float32) (int, int, bool) {
+	cellX := int(math.Floor(float64((x - float32(r.xOffset)) / float32(r.BlockXSize))))
+	cellY := int(math.Floor(float64((y - float32(r.yOffset)) / float32(r.BlockYSize))))
+	inside := cellX >= 0 && cellY >= 0 && cellX <= r.TotalHorizontalBlock && cellY <= r.TotalVerticalBlock
+
+	return cellX, cellY, inside
+}
+
+func (r Renderer) BeginFrame() {
+	rl.BeginDrawing()
+	rl.ClearBackground(r.look().background)
+}
+
+func (r Renderer) EndFrame() {
+	r.drawFlash()
+	rl.EndDrawing()
+}
+
+// Tints the whole window red for a moment, e.g. on a finesse fault
+func (r *Renderer) Flash() {
+	r.flashUntil = time.Now().Add(FLASH_DURATION)
+}
+
+func (r Renderer) drawFlash() {
+	if time.Now().Before(r.flashUntil) {
+		rl.DrawRectangle(0, 0, r.Width, r.Height, rl.Fade(rl.Red, 0.3))
+	}
+}
+
+// Name and score above the board, incoming garbage as a bar on the left of it
+func (r Renderer) DrawVersusInfo(name string, score, lines, incomingGarbage int) {
+	r.drawText(fmt.Sprintf("%s  score: %d  lines: %d", name, score, lines), r.xOffset, r.yOffset-30, 20, r.look().text)
+
+	if incomingGarbage > 0 {
+		barHeight := min(int32(incomingGarbage), int32(r.TotalVerticalBlock+1)) * r.BlockYSize
+		boardBottom := r.yOffset + r.BlockYSize*int32(r.TotalVerticalBlock+1)
+		rl.DrawRectangle(r.xOffset-10, boardBottom-barHeight, 6, barHeight, rl.Red)
+	}
+}
+
+func (r Renderer) RenderVersusResult(message string, names []string, scores []int) {
+	rl.BeginDrawing()
+	rl.ClearBackground(r.look().background)
+	r.drawText(message, r.Width/2-r.measureText(message, 30)/2, r.Height/3, 30, r.look().text)
+
+	for i := range names {
+		line := fmt.Sprintf("%s: %d", names[i], scores[i])
+		r.drawText(line, r.Width/2-r.measureText(line, 20)/2, r.Height/2+int32(i)*30, 20, rl.Fade(r.look().text, 0.7))
 	}
 
-	r.RenderTimeElapsed(elapsedTime)
-	r.RenderScore(currentScore)
-	r.RenderLevel(level)
 	rl.EndDrawing()
 }
 
 func (r Renderer) RenderGainedScore(gainedScore int) {
-	rl.DrawText(fmt.Sprintf("+%d", gainedScore), r.Width/2-2, r.Height/4, 30, rl.White)
+	r.drawText(fmt.Sprintf("+%d", gainedScore), r.Width/2-2, r.Height/4, 30, r.look().text)
 }
 
 func (r Renderer) RenderLevel(level int) {
-	rl.DrawText(fmt.Sprintf("Level: %d", level), r.Width/2-r.xOffset-30, r.Height/12, 20, rl.White)
+	r.drawText(fmt.Sprintf("Level: %d", level), r.Width/2-r.xOffset-30, r.Height/12, 20, r.look().text)
 }
 
 func (r Renderer) RenderScore(score int) {
-	rl.DrawText(fmt.Sprintf("Current Score: %d", score), r.Width/2-r.xOffset-30, r.Height/18, 20, rl.White)
+	r.drawText(fmt.Sprintf("Current Score: %d", score), r.Width/2-r.xOffset-30, r.Height/18, 20, r.look().text)
 }
 
 func (r Renderer) RenderTimeElapsed(elapsedTime time.Duration) {
-	rl.DrawText(fmt.Sprintf("Elapsed Time: %.0f:%.2f", elapsedTime.Minutes(), elapsedTime.Seconds()), r.Width/2+r.xOffset-100, r.Height/18, 20, rl.White)
+	r.drawText(fmt.Sprintf("Elapsed Time: %.0f:%.2f", elapsedTime.Minutes(), elapsedTime.Seconds()), r.Width/2+r.xOffset-100, r.Height/18, 20, r.look().text)
 }
 
 func (r Renderer) RenderLose(score int) {
 	rl.BeginDrawing()
-	rl.ClearBackground(rl.White)
-	rl.DrawText(fmt.Sprintf("You lose with score %d", score), r.xOffset+30, r.Height/2, 20, rl.LightGray)
+	rl.ClearBackground(r.look().background)
+	r.drawText(fmt.Sprintf("You lose with score %d", score), r.xOffset+30, r.Height/2, 20, rl.Fade(r.look().text, 0.7))
+	rl.EndDrawing()
+}
+
+// Lines of a game mode HUD, stacked above the board
+func (r Renderer) RenderHud(lines []string) {
+	for i, line := range lines {
+		r.drawText(line, 10, 10+int32(i)*22, 20, r.look().text)
+	}
+}
+
+func (r Renderer) RenderFinished(title string, lines []string) {
+	rl.BeginDrawing()
+	rl.ClearBackground(r.look().background)
+	r.drawText(title, r.Width/2-r.measureText(title, 30)/2, r.Height/3, 30, r.look().text)
+
+	for i, line := range lines {
+		r.drawText(line, r.Width/2-r.measureText(line, 20)/2, r.Height/2+int32(i)*30, 20, rl.Fade(r.look().text, 0.7))
+	}
+
+	rl.EndDrawing()
+}
+
+// Options stacked in the middle of the window, the selected one is highlighted
+func (r Renderer) RenderMenu(title string, options []string, selected int) {
+	rl.BeginDrawing()
+	rl.ClearBackground(r.look().background)
+	r.drawText(title, r.Width/2-r.measureText(title, 40)/2, r.Height/5, 40, r.look().text)
+
+	for i, option := range options {
+		color := rl.Fade(r.look().text, 0.7)
+		if i == selected {
+			color = r.look().highlight
+		}
+		r.drawText(option, r.Width/2-r.measureText(option, 20)/2, r.Height/3+int32(i)*40, 20, color)
+	}
+
 	rl.EndDrawing()
 }
 
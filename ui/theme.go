@@ -0,0 +1,347 @@
+package renderer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"tetris/entity"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	GHOST_OUTLINE = "outline"
+	GHOST_FILLED  = "filled"
+	GHOST_NONE    = "none"
+)
+
+const DEFAULT_THEME = "guideline"
+
+// How the game looks. Colors are written in hex, e.g. #00f0f0, pieces by their letter and G for garbage.
+// Font and texture paths in a theme file are relative to the file.
+type Theme struct {
+	Name       string            `json:"name"`
+	Pieces     map[string]string `json:"pieces"`
+	Background string            `json:"background"`
+	Grid       string            `json:"grid"`
+	Text       string            `json:"text"`
+	Highlight  string            `json:"highlight"`         // the selected option of a menu
+	Ghost      string            `json:"ghost"`             // outline, filled or none
+	Font       string            `json:"font,omitempty"`    // the raylib font when empty
+	Texture    string            `json:"texture,omitempty"` // image every block is drawn with, tinted in its color
+}
+
+// Color id of the blocks for every letter a theme can color
+var PIECE_LETTER_COLORS map[string]int = map[string]int{
+	"I": entity.PIECE_COLORS[entity.I],
+	"J": entity.PIECE_COLORS[entity.J],
+	"L": entity.PIECE_COLORS[entity.L],
+	"O": entity.PIECE_COLORS[entity.O],
+	"S": entity.PIECE_COLORS[entity.S],
+	"T": entity.PIECE_COLORS[entity.T],
+	"Z": entity.PIECE_COLORS[entity.Z],
+	"G": entity.GRAY,
+}
+
+var THEMES []Theme = []Theme{
+	{
+		Name:       DEFAULT_THEME,
+		Pieces:     map[string]string{"I": "#00f0f0", "J": "#0000f0", "L": "#f0a000", "O": "#f0f000", "S": "#00f000", "T": "#a000f0", "Z": "#f00000", "G": "#828282"},
+		Background: "#000000",
+		Grid:       "#282828",
+		Text:       "#ffffff",
+		Highlight:  "#f0f000",
+		Ghost:      GHOST_OUTLINE,
+	},
+	// the colors the game had before the pieces got their own
+	{
+		Name:       "classic",
+		Pieces:     map[string]string{"I": "#0079f1", "J": "#0079f1", "L": "#fdf900", "O": "#fdf900", "S": "#00e430", "T": "#e62937", "Z": "#e62937", "G": "#828282"},
+		Background: "#000000",
+		Grid:       "#828282",
+		Text:       "#ffffff",
+		Highlight:  "#fdf900",
+		Ghost:      GHOST_OUTLINE,
+	},
+	{
+		Name:       "light",
+		Pieces:     map[string]string{"I": "#2bb5c8", "J": "#3a5bc7", "L": "#e8891c", "O": "#e3c21a", "S": "#4caf50", "T": "#9c4dcc", "Z": "#d83c3c", "G": "#9e9e9e"},
+		Background: "#f4f4f4",
+		Grid:       "#dddddd",
+		Text:       "#222222",
+		Highlight:  "#e8891c",
+		Ghost:      GHOST_FILLED,
+	},
+}
+
+func (t Theme) Validate() error {
+	if t.Name == "" {
+		return errors.New("Theme needs a name")
+	}
+
+	for letter, hex := range t.Pieces {
+		if _, ok := PIECE_LETTER_COLORS[letter]; !ok {
+			return errors.New(fmt.Sprintf("Unknown piece %s in theme %s", letter, t.Name))
+		}
+		if _, err := ParseColor(hex); err != nil {
+			return err
+		}
+	}
+
+	for _, hex := range []string{t.Background, t.Grid, t.Text, t.Highlight} {
+		if _, err := ParseColor(hex); hex != "" && err != nil {
+			return err
+		}
+	}
+
+	if t.Ghost != "" && !slices.Contains([]string{GHOST_OUTLINE, GHOST_FILLED, GHOST_NONE}, t.Ghost) {
+		return errors.New(fmt.Sprintf("Unknown ghost style %s in theme %s", t.Ghost, t.Name))
+	}
+
+	return nil
+}
+
+// e.g. #00f0f0, or with an alpha channel #00f0f080
+func ParseColor(hex string) (color.RGBA, error) {
+	parsed := color.RGBA{A: 255}
+
+	var err error
+	switch len(hex) {
+	case 7:
+		_, err = fmt.Sscanf(hex, "#%02x%02x%02x", &parsed.R, &parsed.G, &parsed.B)
+	case 9:
+		_, err = fmt.Sscanf(hex, "#%02x%02x%02x%02x", &parsed.R, &parsed.G, &parsed.B, &parsed.A)
+	default:
+		err = errors.New("wrong length")
+	}
+
+	if err != nil {
+		return parsed, errors.New(fmt.Sprintf("Color %s should look like #00f0f0", hex))
+	}
+
+	return parsed, nil
+}
+
+func LoadTheme(path string) (Theme, error) {
+	theme := Theme{}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return theme, err
+	}
+
+	if err := json.Unmarshal(content, &theme); err != nil {
+		return theme, err
+	}
+
+	if theme.Name == "" {
+		theme.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	for _, file := range []*string{&theme.Font, &theme.Texture} {
+		if *file != "" && !filepath.IsAbs(*file) {
+			*file = filepath.Join(filepath.Dir(path), *file)
+		}
+	}
+
+	return theme, theme.Validate()
+}
+
+// Next to the other settings of the user
+func DefaultThemesDirectory() string {
+	configDirectory, err := os.UserConfigDir()
+	if err != nil {
+		return "themes"
+	}
+
+	return filepath.Join(configDirectory, "tetris", "themes")
+}
+
+// The built in themes followed by the theme files of the directory, a missing directory only has the built in ones.
+// A file with the name of a built in theme replaces it.
+func LoadThemes(directory string) ([]Theme, error) {
+	themes := slices.Clone(THEMES)
+
+	paths, err := filepath.Glob(filepath.Join(directory, "*.json"))
+	if err != nil {
+		return themes, err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		theme, err := LoadTheme(path)
+		if err != nil {
+			return themes, errors.New(fmt.Sprintf("%s: %s", path, err.Error()))
+		}
+
+		index := slices.IndexFunc(themes, func(builtIn Theme) bool { return builtIn.Name == theme.Name })
+		if index >= 0 {
+			themes[index] = theme
+		} else {
+			themes = append(themes, theme)
+		}
+	}
+
+	return themes, nil
+}
+
+// What a theme looks like once it is loaded, missing values fall back to the default theme
+type look struct {
+	name       string
+	colors     map[int]color.RGBA
+	background color.RGBA
+	grid       color.RGBA
+	text       color.RGBA
+	highlight  color.RGBA
+	ghost      string
+	font       *rl.Font
+	texture    *rl.Texture2D
+}
+
+func newLook(theme Theme) look {
+	fallback := THEMES[0]
+	l := look{name: theme.Name, colors: make(map[int]color.RGBA), ghost: theme.Ghost}
+
+	for letter, colorID := range PIECE_LETTER_COLORS {
+		hex, ok := theme.Pieces[letter]
+		if !ok {
+			hex = fallback.Pieces[letter]
+		}
+		l.colors[colorID], _ = ParseColor(hex)
+	}
+
+	for _, value := range []struct {
+		target   *color.RGBA
+		hex      string
+		fallback string
+	}{{&l.background, theme.Background, fallback.Background}, {&l.grid, theme.Grid, fallback.Grid}, {&l.text, theme.Text, fallback.Text}, {&l.highlight, theme.Highlight, fallback.Highlight}} {
+		if value.hex == "" {
+			value.hex = value.fallback
+		}
+		*value.target, _ = ParseColor(value.hex)
+	}
+
+	if l.ghost == "" {
+		l.ghost = fallback.Ghost
+	}
+
+	return l
+}
+
+var defaultLook look = newLook(THEMES[0])
+
+// Themes to switch between while playing, shared by every copy of the renderer
+type Themes struct {
+	List    []Theme
+	Current int
+	look    *look
+}
+
+func NewThemes(themes []Theme, name string) (*Themes, error) {
+	index := slices.IndexFunc(themes, func(theme Theme) bool { return theme.Name == name })
+	if index < 0 {
+		return nil, errors.New(fmt.Sprintf("Unknown theme %s", name))
+	}
+
+	return &Themes{List: themes, Current: index}, nil
+}
+
+// Loads the font and the texture of the current theme, the window has to be open
+func (t *Themes) load() {
+	if t.look != nil {
+		if t.look.font != nil {
+			rl.UnloadFont(*t.look.font)
+		}
+		if t.look.texture != nil {
+			rl.UnloadTexture(*t.look.texture)
+		}
+	}
+
+	theme := t.List[t.Current]
+	loaded := newLook(theme)
+
+	if theme.Font != "" {
+		font := rl.LoadFont(theme.Font)
+		loaded.font = &font
+	}
+
+	if theme.Texture != "" {
+		texture := rl.LoadTexture(theme.Texture)
+		loaded.texture = &texture
+	}
+
+	t.look = &loaded
+}
+
+// Switches to the next theme, e.g. while playing
+func (r Renderer) NextTheme() {
+	if r.Themes == nil || len(r.Themes.List) == 0 {
+		return
+	}
+
+	r.Themes.Current = (r.Themes.Current + 1) % len(r.Themes.List)
+	r.Themes.load()
+}
+
+func (r Renderer) look() look {
+	if r.Themes == nil || r.Themes.look == nil {
+		return defaultLook
+	}
+
+	return *r.Themes.look
+}
+
+func (r Renderer) blockColor(colorID int) color.RGBA {
+	if blockColor, ok := r.look().colors[colorID]; ok {
+		return blockColor
+	}
+
+	return BLOCK_COLORS[colorID]
+}
+
+// Single cell of a block, textured when the theme has a texture
+func (r Renderer) drawCell(x, y float32, colorID int) {
+	current := r.look()
+	if current.texture != nil {
+		source := rl.Rectangle{Width: float32(current.texture.Width), Height: float32(current.texture.Height)}
+		destination := rl.Rectangle{X: x, Y: y, Width: float32(r.BlockXSize), Height: float32(r.BlockYSize)}
+		rl.DrawTexturePro(*current.texture, source, destination, rl.Vector2{}, 0, r.blockColor(colorID))
+		return
+	}
+
+	rl.DrawRectangleV(rl.Vector2{X: x, Y: y}, rl.Vector2{X: float32(r.BlockXSize), Y: float32(r.BlockYSize)}, r.blockColor(colorID))
+}
+
+// Outline, see through block or nothing, depending on the theme
+func (r Renderer) drawGhostCell(x, y float32, colorID int) {
+	switch r.look().ghost {
+	case GHOST_FILLED:
+		rl.DrawRectangleV(rl.Vector2{X: x, Y: y}, rl.Vector2{X: float32(r.BlockXSize), Y: float32(r.BlockYSize)}, rl.Fade(r.blockColor(colorID), 0.3))
+	case GHOST_OUTLINE:
+		rl.DrawRectangleLines(int32(x), int32(y), r.BlockXSize, r.BlockYSize, r.blockColor(colorID))
+	}
+}
+
+// Text in the font of the theme
+func (r Renderer) drawText(text string, x, y, size int32, textColor color.RGBA) {
+	if font := r.look().font; font != nil {
+		rl.DrawTextEx(*font, text, rl.Vector2{X: float32(x), Y: float32(y)}, float32(size), 1, textColor)
+		return
+	}
+
+	rl.DrawText(text, x, y, size, textColor)
+}
+
+func (r Renderer) measureText(text string, size int32) int32 {
+	if font := r.look().font; font != nil {
+		return int32(rl.MeasureTextEx(*font, text, float32(size), 1).X)
+	}
+
+	return rl.MeasureText(text, size)
+}
@@ -0,0 +1,59 @@
+package renderer
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	if parsed, err := ParseColor("#00f0f0"); err != nil || parsed != (color.RGBA{R: 0, G: 240, B: 240, A: 255}) {
+		t.Errorf("Expected cyan, found %v %v", parsed, err)
+		t.Fail()
+	}
+
+	if parsed, err := ParseColor("#00000080"); err != nil || parsed.A != 128 {
+		t.Errorf("Expected a see through black, found %v %v", parsed, err)
+		t.Fail()
+	}
+
+	if _, err := ParseColor("cyan"); err == nil {
+		t.Error("Colors have to be written in hex")
+		t.Fail()
+	}
+}
+
+func TestLoadThemes(t *testing.T) {
+	themes, err := LoadThemes(filepath.Join("..", "themes"))
+	if err != nil {
+		t.Error(err.Error())
+		t.FailNow()
+	}
+
+	if len(themes) != len(THEMES)+1 || themes[len(themes)-1].Name != "neon" {
+		t.Errorf("Expected the built in themes and neon, found %d themes", len(themes))
+		t.Fail()
+	}
+
+	// a theme file with the name of a built in theme replaces it, its texture is found next to it
+	directory := t.TempDir()
+	os.WriteFile(filepath.Join(directory, "guideline.json"), []byte(`{"name": "guideline", "pieces": {"T": "#ff00ff"}, "texture": "block.png"}`), 0644)
+
+	themes, err = LoadThemes(directory)
+	if err != nil || len(themes) != len(THEMES) || themes[0].Texture != filepath.Join(directory, "block.png") {
+		t.Errorf("Expected the guideline theme to be replaced, found %v %v", themes[0], err)
+		t.FailNow()
+	}
+
+	if newLook(themes[0]).colors[PIECE_LETTER_COLORS["I"]] != defaultLook.colors[PIECE_LETTER_COLORS["I"]] {
+		t.Error("Pieces the theme leaves out should keep their default color")
+		t.Fail()
+	}
+
+	os.WriteFile(filepath.Join(directory, "broken.json"), []byte(`{"ghost": "dotted"}`), 0644)
+	if _, err := LoadThemes(directory); err == nil {
+		t.Error("Unknown ghost style should not load")
+		t.Fail()
+	}
+}
@@ -0,0 +1,76 @@
+package versus
+
+import "tetris/game"
+
+// Rows a combo adds on top of the clear, by how many clears in a row came before it
+var COMBO_TABLE []int = []int{0, 1, 1, 2, 2, 3, 3, 4, 4, 4, 5}
+
+const (
+	BACK_TO_BACK_BONUS   = 1
+	PERFECT_CLEAR_ATTACK = 10
+	// a T-spin sends this many rows for every line it clears
+	TSPIN_ATTACK = 2
+)
+
+// Works out the garbage the clears of a single game send, the clears before decide on combo and back to back
+type Attack struct {
+	Combo      int  // the first clear of a streak is combo 0, -1 outside of a streak
+	BackToBack bool // the last clear was a tetris or a T-spin with lines
+	cleared    bool // whether the last lock cleared lines
+}
+
+func NewAttack() *Attack {
+	return &Attack{Combo: -1}
+}
+
+// Follows the events of the game, calls sent with the rows every clear sends
+func Follow(events *game.Events, sent func(rows int)) *Attack {
+	attack := NewAttack()
+
+	events.Subscribe(func(event game.Event) {
+		switch event := event.(type) {
+		case game.PieceLocked:
+			attack.Lock()
+		case game.LinesCleared:
+			sent(attack.Clear(event))
+		}
+	})
+
+	return attack
+}
+
+// The clear of a lock comes right after it, only a lock before it without one ends the combo
+func (a *Attack) Lock() {
+	if !a.cleared {
+		a.Combo = -1
+	}
+	a.cleared = false
+}
+
+// Rows the clear sends
+func (a *Attack) Clear(event game.LinesCleared) int {
+	if event.Count == 0 {
+		return 0
+	}
+
+	rows := ATTACK_TABLE[min(event.Count, 4)]
+	if event.TSpin {
+		rows = TSPIN_ATTACK * event.Count
+	}
+
+	difficult := event.Count >= 4 || event.TSpin
+	if difficult && a.BackToBack {
+		rows += BACK_TO_BACK_BONUS
+	}
+	a.BackToBack = difficult
+
+	a.cleared = true
+	a.Combo += 1
+	rows += COMBO_TABLE[min(a.Combo, len(COMBO_TABLE)-1)]
+
+	if event.PerfectClear {
+		rows += PERFECT_CLEAR_ATTACK
+	}
+
+	return rows
+}
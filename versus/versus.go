@@ -0,0 +1,183 @@
+package versus
+
+import (
+	"tetris/collision"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+	renderer "tetris/ui"
+)
+
+const (
+	NO_WINNER = -1
+	DRAW      = -2
+)
+
+// the rows of one attack line up, the next attack most likely has its hole somewhere else
+const DEFAULT_HOLE_STRATEGY = "random"
+
+// Garbage rows sent to the opponent for the amount of lines cleared by a single block
+var ATTACK_TABLE map[int]int = map[int]int{
+	1: 0,
+	2: 1,
+	3: 2,
+	4: 4,
+}
+
+type Player struct {
+	Name           string
+	Game           *game.TetrisGame
+	EventHandler   eventhandler.EventHandler
+	PendingGarbage int // rows waiting to be pushed in once the current block locks
+	SentGarbage    int // rows sent to the opponents on the last tick
+	Holes          collision.HoleStrategy
+	lastLines      int
+	attack         int // rows the clears of the current tick send, before they cancel pending garbage
+}
+
+// Two games side by side, clearing lines on one board pushes garbage onto the other one
+type Match struct {
+	Players  []*Player
+	Renderer renderer.Renderer
+	Winner   int
+}
+
+// Both games are seeded with the same seed so the players get the same sequence of blocks
+func New(rules game.Rules, seed int64, names []string, handlers []eventhandler.EventHandler) *Match {
+	match := &Match{Winner: NO_WINNER}
+
+	for i := range names {
+		tetrisGame := game.NewFromRules(rules, seed)
+		player := &Player{
+			Name:         names[i],
+			Game:         &tetrisGame,
+			EventHandler: handlers[i],
+			Holes:        collision.HOLE_STRATEGIES[DEFAULT_HOLE_STRATEGY](seed + int64(i) + 1),
+		}
+		Follow(tetrisGame.Events, func(rows int) { player.attack += rows })
+		tetrisGame.Start()
+
+		match.Players = append(match.Players, player)
+	}
+
+	return match
+}
+
+// Advances every game by a single tick and exchanges the garbage
+func (m *Match) Update(events []eventhandler.UpdateEvent) {
+	if m.Winner != NO_WINNER {
+		return
+	}
+
+	cleared := make([]int, len(m.Players))
+
+	for i, player := range m.Players {
+		player.Game.Update(events[i])
+		cleared[i] = player.Game.Lines - player.lastLines
+		player.lastLines = player.Game.Lines
+	}
+
+	for i, player := range m.Players {
+		attack := player.attack
+		player.attack = 0
+
+		// sending garbage cancels the garbage that is still on its way first
+		cancelled := min(attack, player.PendingGarbage)
+		player.PendingGarbage -= cancelled
+		attack -= cancelled
+		player.SentGarbage = attack
+
+		for j, opponent := range m.Players {
+			if j != i {
+				opponent.PendingGarbage += attack
+			}
+		}
+	}
+
+	for i, player := range m.Players {
+		// a block that cleared lines holds the garbage back until the next one locks
+		if player.Game.BlockState != game.SPAWNING_BLOCK || player.PendingGarbage == 0 || cleared[i] > 0 {
+			continue
+		}
+
+		player.Game.AddGarbage(player.Holes.Holes(player.PendingGarbage, player.Game.MaxWitdh+1))
+		player.PendingGarbage = 0
+	}
+
+	m.Winner = m.winner()
+}
+
+// The last player still standing, or DRAW when everyone topped out on the same tick
+func (m *Match) winner() int {
+	standing := make([]int, 0, len(m.Players))
+
+	for i, player := range m.Players {
+		if player.Game.State != game.LOSE {
+			standing = append(standing, i)
+		}
+	}
+
+	if len(standing) == 0 {
+		return DRAW
+	}
+
+	if len(standing) == 1 {
+		return standing[0]
+	}
+
+	return NO_WINNER
+}
+
+// Opens the window and spreads the boards over it
+func (m *Match) Init(title string) {
+	m.Renderer.Init(title)
+
+	for i, origin := range m.Renderer.BoardOrigins(len(m.Players)) {
+		m.Players[i].Game.Renderer = m.Renderer
+		m.Players[i].Game.Renderer.SetBoardOrigin(origin[0], origin[1])
+	}
+}
+
+func (m *Match) Play() {
+	m.Init("Tetris Versus")
+	defer m.Renderer.Close()
+
+	for !m.Renderer.ShouldClose() {
+		events := make([]eventhandler.UpdateEvent, len(m.Players))
+		for i, player := range m.Players {
+			events[i] = player.EventHandler.HandleEvent()
+		}
+
+		m.Update(events)
+		m.Render()
+	}
+}
+
+func (m *Match) Results() ([]string, []int) {
+	names := make([]string, len(m.Players))
+	scores := make([]int, len(m.Players))
+	for i, player := range m.Players {
+		names[i], scores[i] = player.Name, player.Game.Score
+	}
+
+	return names, scores
+}
+
+func (m *Match) Render() {
+	if m.Winner != NO_WINNER {
+		message := "Draw"
+		if m.Winner != DRAW {
+			message = m.Players[m.Winner].Name + " wins"
+		}
+
+		names, scores := m.Results()
+		m.Renderer.RenderVersusResult(message, names, scores)
+		return
+	}
+
+	m.Renderer.BeginFrame()
+	for _, player := range m.Players {
+		player.Game.DrawBoard()
+		player.Game.Renderer.DrawVersusInfo(player.Name, player.Game.Score, player.Game.Lines, player.PendingGarbage)
+	}
+	m.Renderer.EndFrame()
+}
@@ -0,0 +1,154 @@
+package versus
+
+import (
+	"testing"
+	"tetris/bot"
+	"tetris/entity"
+	eventhandler "tetris/event_handler"
+	"tetris/game"
+)
+
+func newMatch(seed int64) *Match {
+	return New(game.RULE_SETS["standard"], seed, []string{"left", "right"}, []eventhandler.EventHandler{nil, nil})
+}
+
+func TestAddGarbage(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	tetrisGame.Start()
+	tetrisGame.CollisionDetector.AddOccupiedBlocks(0, tetrisGame.MaxHeight)
+
+	toppedOut := tetrisGame.AddGarbage([]int{3, 3})
+	currentBoard := tetrisGame.Board()
+
+	if toppedOut {
+		t.Error("Two rows of garbage should not top out an empty board")
+		t.FailNow()
+	}
+
+	if !currentBoard.Occupied(0, tetrisGame.MaxHeight-2) || currentBoard.Cells[tetrisGame.MaxHeight][0] != entity.GRAY {
+		t.Error("Existing blocks should be pushed up by the garbage")
+		t.Fail()
+	}
+
+	for y := tetrisGame.MaxHeight - 1; y <= tetrisGame.MaxHeight; y++ {
+		for x := range currentBoard.Width {
+			if currentBoard.Occupied(x, y) == (x == 3) {
+				t.Errorf("Cell x: %d y: %d of the garbage is wrong, the hole should be at x: 3", x, y)
+				t.Fail()
+			}
+		}
+
+		if tetrisGame.CollisionDetector.GetYCount(y) != tetrisGame.MaxWitdh {
+			t.Errorf("Garbage row %d should count %d blocks, found %d", y, tetrisGame.MaxWitdh, tetrisGame.CollisionDetector.GetYCount(y))
+			t.Fail()
+		}
+	}
+}
+
+func TestAddGarbageTopsOut(t *testing.T) {
+	tetrisGame := game.NewFromRules(game.RULE_SETS["standard"], 1)
+	tetrisGame.Start()
+	tetrisGame.CollisionDetector.AddOccupiedBlocks(0, 1)
+
+	if !tetrisGame.AddGarbage([]int{0, 0}) {
+		t.Error("A block pushed over the top should top out the game")
+		t.FailNow()
+	}
+
+	if tetrisGame.State != game.LOSE {
+		t.Error("Game should be lost after topping out")
+		t.Fail()
+	}
+}
+
+func TestAttackCancelsPendingGarbage(t *testing.T) {
+	match := newMatch(1)
+	attacker, defender := match.Players[0], match.Players[1]
+	attacker.PendingGarbage = 3
+
+	// pretend the attacker just cleared a tetris
+	attacker.attack = ATTACK_TABLE[4]
+	match.Update(make([]eventhandler.UpdateEvent, 2))
+
+	if attacker.PendingGarbage != 0 {
+		t.Errorf("Attack should cancel the pending garbage first, %d rows are left", attacker.PendingGarbage)
+		t.Fail()
+	}
+
+	if defender.PendingGarbage != ATTACK_TABLE[4]-3 {
+		t.Errorf("Defender should receive %d rows, found %d", ATTACK_TABLE[4]-3, defender.PendingGarbage)
+		t.Fail()
+	}
+}
+
+func TestAttackBonuses(t *testing.T) {
+	attack := NewAttack()
+	clears := []struct {
+		event game.LinesCleared
+		rows  int
+	}{
+		{game.LinesCleared{Count: 4}, 4},
+		// back to back and the first bonus of the combo
+		{game.LinesCleared{Count: 2, TSpin: true}, 4 + BACK_TO_BACK_BONUS + 1},
+		// a single breaks back to back
+		{game.LinesCleared{Count: 1}, 0 + 1},
+		{game.LinesCleared{Count: 4, PerfectClear: true}, 4 + 2 + PERFECT_CLEAR_ATTACK},
+	}
+
+	for _, clear := range clears {
+		attack.Lock()
+		if rows := attack.Clear(clear.event); rows != clear.rows {
+			t.Errorf("%s should send %d rows, found %d", clear.event.Type(), clear.rows, rows)
+			t.Fail()
+		}
+	}
+
+	// a lock without a clear ends the combo
+	attack.Lock()
+	attack.Lock()
+	if rows := attack.Clear(game.LinesCleared{Count: 2}); rows != ATTACK_TABLE[2] || attack.BackToBack {
+		t.Errorf("Double after the combo ended should send %d rows, found %d", ATTACK_TABLE[2], rows)
+		t.Fail()
+	}
+}
+
+func TestPlayersGetTheSameBlocks(t *testing.T) {
+	match := newMatch(7)
+	match.Update(make([]eventhandler.UpdateEvent, 2))
+
+	left, right := match.Players[0].Game, match.Players[1].Game
+	if left.CurrentBlock.EntityType != right.CurrentBlock.EntityType || !left.CurrentBlock.OccupiedPosition.Equal(right.CurrentBlock.OccupiedPosition) {
+		t.Error("Both players should start with the same block")
+		t.Fail()
+	}
+
+	for i := range left.NextBlocks {
+		if left.NextBlocks[i].EntityType != right.NextBlocks[i].EntityType {
+			t.Errorf("Preview block %d differs between the players", i)
+			t.Fail()
+		}
+	}
+}
+
+func TestMatchEndsWithAWinner(t *testing.T) {
+	match := newMatch(3)
+	heuristicBot := bot.New(bot.DEFAULT_WEIGHTS)
+	randomBot := bot.NewRandom(3)
+
+	for tick := 0; match.Winner == NO_WINNER; tick++ {
+		if tick > 200000 {
+			t.Error("Match should be over by now")
+			t.FailNow()
+		}
+
+		match.Update([]eventhandler.UpdateEvent{
+			heuristicBot.NextEvent(match.Players[0].Game),
+			randomBot.NextEvent(match.Players[1].Game),
+		})
+	}
+
+	if match.Winner != 0 {
+		t.Errorf("Heuristic bot should beat the random one, winner is %d", match.Winner)
+		t.Fail()
+	}
+}